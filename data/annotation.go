@@ -0,0 +1,252 @@
+package data
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+type AnnotationKind int
+
+const (
+	AnnotationRect AnnotationKind = iota
+	AnnotationArrow
+	AnnotationMarker
+	AnnotationHighlighter
+	AnnotationText
+)
+
+var AnnotationKinds = []AnnotationKind{
+	AnnotationRect, AnnotationArrow, AnnotationMarker, AnnotationHighlighter, AnnotationText,
+}
+
+func (k AnnotationKind) String() string {
+	switch k {
+	case AnnotationRect:
+		return "Rectangle"
+	case AnnotationArrow:
+		return "Arrow"
+	case AnnotationMarker:
+		return "Marker"
+	case AnnotationHighlighter:
+		return "Highlighter"
+	case AnnotationText:
+		return "Text"
+	default:
+		return ""
+	}
+}
+
+// Annotation is a shape drawn over the merged image. Points are in merged
+// image coordinates: two corners for rectangles, tail and head for arrows,
+// the stroke path for marker and highlighter, and the top-left corner for
+// text. Size is the stroke width, or the font size for text.
+type Annotation struct {
+	Kind   AnnotationKind
+	Points []image.Point
+	Color  color.NRGBA
+	Size   float64
+	Text   string
+}
+
+const highlighterAlpha = 0.4
+
+func (a *Annotation) Translate(d image.Point) {
+	for i := range a.Points {
+		a.Points[i] = a.Points[i].Add(d)
+	}
+}
+
+func (a *Annotation) Bounds() image.Rectangle {
+	if len(a.Points) == 0 {
+		return image.Rectangle{}
+	}
+	if a.Kind == AnnotationText {
+		w, h := measureText(a.Text, a.Size)
+		return image.Rectangle{a.Points[0], a.Points[0].Add(image.Pt(w, h))}
+	}
+
+	r := image.Rectangle{a.Points[0], a.Points[0].Add(image.Pt(1, 1))}
+	for _, p := range a.Points[1:] {
+		r = r.Union(image.Rectangle{p, p.Add(image.Pt(1, 1))})
+	}
+	pad := int(math.Ceil(a.strokeWidth()))
+	if a.Kind == AnnotationArrow {
+		pad = int(math.Ceil(a.arrowHead()))
+	}
+	return r.Inset(-pad)
+}
+
+func (a *Annotation) strokeWidth() float64 {
+	if a.Kind == AnnotationHighlighter {
+		return a.Size * 4
+	}
+	return a.Size
+}
+
+func (a *Annotation) arrowHead() float64 {
+	return max(a.Size*4, 12)
+}
+
+func DrawAnnotations(dst draw.Image, annotations []*Annotation, origin image.Point, scale float64) {
+	for _, a := range annotations {
+		b := a.Bounds()
+		target := image.Rect(
+			int(math.Floor(float64(b.Min.X-origin.X)*scale)), int(math.Floor(float64(b.Min.Y-origin.Y)*scale)),
+			int(math.Ceil(float64(b.Max.X-origin.X)*scale)), int(math.Ceil(float64(b.Max.Y-origin.Y)*scale)),
+		)
+		if !target.Overlaps(dst.Bounds()) {
+			continue
+		}
+
+		layer := image.NewRGBA(image.Rect(0, 0, target.Dx(), target.Dy()))
+		toLayer := func(p image.Point) (float64, float64) {
+			return float64(p.X-origin.X)*scale - float64(target.Min.X),
+				float64(p.Y-origin.Y)*scale - float64(target.Min.Y)
+		}
+		a.draw(layer, toLayer, scale)
+		draw.Draw(dst, target, layer, image.Point{}, draw.Over)
+	}
+}
+
+func (a *Annotation) draw(dst *image.RGBA, at func(image.Point) (float64, float64), scale float64) {
+	w, h := dst.Bounds().Dx(), dst.Bounds().Dy()
+	scanner := rasterx.NewScannerGV(w, h, dst, dst.Bounds())
+	stroker := rasterx.NewStroker(w, h, scanner)
+	width := fixed.Int26_6(a.strokeWidth() * scale * 64)
+	clr := a.Color
+
+	switch a.Kind {
+	case AnnotationRect:
+		if len(a.Points) < 2 {
+			return
+		}
+		x0, y0 := at(a.Points[0])
+		x1, y1 := at(a.Points[1])
+		stroker.SetStroke(width, 4*64, rasterx.ButtCap, nil, nil, rasterx.Miter)
+		rasterx.AddRect(min(x0, x1), min(y0, y1), max(x0, x1), max(y0, y1), 0, stroker)
+	case AnnotationArrow:
+		if len(a.Points) < 2 {
+			return
+		}
+		x0, y0 := at(a.Points[0])
+		x1, y1 := at(a.Points[1])
+		dx, dy := x1-x0, y1-y0
+		l := math.Hypot(dx, dy)
+		if l == 0 {
+			return
+		}
+		ux, uy := dx/l, dy/l
+		head := min(a.arrowHead()*scale, l)
+		bx, by := x1-ux*head, y1-uy*head
+
+		stroker.SetStroke(width, 4*64, rasterx.RoundCap, nil, nil, rasterx.Round)
+		stroker.Start(rasterx.ToFixedP(x0, y0))
+		stroker.Line(rasterx.ToFixedP(bx+ux*head/2, by+uy*head/2))
+		stroker.Stop(false)
+		scanner.SetColor(clr)
+		stroker.Draw()
+		stroker.Clear()
+
+		filler := rasterx.NewFiller(w, h, scanner)
+		filler.Start(rasterx.ToFixedP(x1, y1))
+		filler.Line(rasterx.ToFixedP(bx-uy*head/2, by+ux*head/2))
+		filler.Line(rasterx.ToFixedP(bx+uy*head/2, by-ux*head/2))
+		filler.Stop(true)
+		filler.Draw()
+		return
+	case AnnotationMarker, AnnotationHighlighter:
+		if len(a.Points) == 0 {
+			return
+		}
+		if a.Kind == AnnotationHighlighter {
+			clr.A = uint8(float64(clr.A) * highlighterAlpha)
+			stroker.SetStroke(width, 4*64, rasterx.SquareCap, nil, nil, rasterx.Round)
+		} else {
+			stroker.SetStroke(width, 4*64, rasterx.RoundCap, nil, nil, rasterx.Round)
+		}
+		stroker.Start(rasterx.ToFixedP(at(a.Points[0])))
+		for _, p := range a.Points[1:] {
+			stroker.Line(rasterx.ToFixedP(at(p)))
+		}
+		if len(a.Points) == 1 {
+			x, y := at(a.Points[0])
+			stroker.Line(rasterx.ToFixedP(x+0.01, y))
+		}
+		stroker.Stop(false)
+	case AnnotationText:
+		if len(a.Points) == 0 || a.Size*scale < 1 {
+			return
+		}
+		face := textFace(a.Size * scale)
+		defer face.Close()
+		x, y := at(a.Points[0])
+		d := font.Drawer{
+			Dst:  dst,
+			Src:  image.NewUniform(clr),
+			Face: face,
+			Dot:  fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y*64) + face.Metrics().Ascent},
+		}
+		for i, line := range splitLines(a.Text) {
+			d.Dot.X = fixed.Int26_6(x * 64)
+			if i > 0 {
+				d.Dot.Y += face.Metrics().Height
+			}
+			d.DrawString(line)
+		}
+		return
+	}
+
+	scanner.SetColor(clr)
+	stroker.Draw()
+}
+
+var parseTextFont = sync.OnceValue(func() *opentype.Font {
+	f, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		panic(err)
+	}
+	return f
+})
+
+func textFace(size float64) font.Face {
+	face, err := opentype.NewFace(parseTextFont(), &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return face
+}
+
+func measureText(text string, size float64) (int, int) {
+	if size <= 0 {
+		return 0, 0
+	}
+	face := textFace(size)
+	defer face.Close()
+
+	w := fixed.Int26_6(0)
+	lines := splitLines(text)
+	for _, line := range lines {
+		w = max(w, font.MeasureString(face, line))
+	}
+	m := face.Metrics()
+	h := m.Height*fixed.Int26_6(len(lines)-1) + m.Ascent + m.Descent
+	return w.Ceil(), h.Ceil()
+}
+
+func splitLines(text string) []string {
+	return strings.Split(text, "\n")
+}
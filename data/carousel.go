@@ -0,0 +1,178 @@
+package data
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+)
+
+var CarouselSizes = []image.Point{{1080, 1350}, {1080, 1920}, {1080, 1080}}
+
+// Carousel cuts the merged image, scaled to Width, into Width×Height
+// slides. Consecutive slides share Overlap rows. With Snap, each cut is
+// moved up to the nearest blank row so it does not slice through text.
+type Carousel struct {
+	Width, Height int
+	Overlap       int
+	Indicator     bool
+	Snap          bool
+	Background    color.NRGBA
+}
+
+var DefaultCarousel = Carousel{
+	Width:      1080,
+	Height:     1350,
+	Indicator:  true,
+	Snap:       true,
+	Background: color.NRGBA{0xff, 0xff, 0xff, 0xff},
+}
+
+const (
+	carouselSnapTolerance = 8
+	carouselDotRadius     = 8
+	carouselDotSpacing    = 28
+)
+
+func (c Carousel) step() int {
+	return max(c.Height-c.Overlap, 1)
+}
+
+// Scale resizes img to the slide width.
+func (c Carousel) Scale(img image.Image) image.Image {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dx() == c.Width {
+		return img
+	}
+	h := max(int(float64(b.Dy())*float64(c.Width)/float64(b.Dx())+0.5), 1)
+	dst := image.NewNRGBA(image.Rect(0, 0, c.Width, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// Cuts returns the first row of every slide of img. The given cuts are
+// kept as they are and the remaining ones are placed after them.
+func (c Carousel) Cuts(img image.Image, fixed []int) []int {
+	cuts := slices.Clone(fixed)
+	if len(cuts) == 0 {
+		cuts = []int{0}
+	}
+	h := img.Bounds().Dy()
+	for last := cuts[len(cuts)-1]; last+c.Height < h; last = cuts[len(cuts)-1] {
+		next := last + c.step()
+		if c.Snap {
+			next = snapCut(img, next, last+c.step()/2)
+		}
+		cuts = append(cuts, next)
+	}
+	return cuts
+}
+
+// MaxCut returns the largest valid position for cut i, which keeps slide
+// i-1 from dropping rows.
+func (c Carousel) MaxCut(cuts []int, i int) int {
+	return cuts[i-1] + c.step()
+}
+
+func snapCut(img image.Image, y, limit int) int {
+	b := img.Bounds()
+	for v := y; v > limit; v-- {
+		if uniformRow(img, b.Min.Y+v) {
+			return v
+		}
+	}
+	return y
+}
+
+func uniformRow(img image.Image, y int) bool {
+	b := img.Bounds()
+	r0, g0, b0, _ := img.At(b.Min.X, y).RGBA()
+	for x := b.Min.X + 1; x < b.Max.X; x++ {
+		r, g, bl, _ := img.At(x, y).RGBA()
+		if absDiff(r, r0) > carouselSnapTolerance<<8 || absDiff(g, g0) > carouselSnapTolerance<<8 || absDiff(bl, b0) > carouselSnapTolerance<<8 {
+			return false
+		}
+	}
+	return true
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// Slides cuts img, as returned by Scale, at cuts. A slide ends where the
+// next one starts, plus Overlap; any space left below is filled with
+// Background.
+func (c Carousel) Slides(img image.Image, cuts []int) []image.Image {
+	b := img.Bounds()
+	slides := make([]image.Image, len(cuts))
+	for i, cut := range cuts {
+		end := min(cut+c.Height, b.Dy())
+		if i+1 < len(cuts) {
+			end = min(end, cuts[i+1]+c.Overlap)
+		}
+		dst := image.NewNRGBA(image.Rect(0, 0, c.Width, c.Height))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(c.Background), image.Point{}, draw.Src)
+		draw.Draw(dst, image.Rect(0, 0, c.Width, end-cut), img, b.Min.Add(image.Pt(0, cut)), draw.Over)
+		if c.Indicator && len(cuts) > 1 {
+			drawIndicator(dst, i, len(cuts))
+		}
+		slides[i] = dst
+	}
+	return slides
+}
+
+func drawIndicator(dst *image.NRGBA, current, count int) {
+	b := dst.Bounds()
+	w := (count-1)*carouselDotSpacing + carouselDotRadius*4
+	h := carouselDotRadius * 4
+	pill := image.Rect(b.Dx()/2-w/2, b.Dy()-h*2, b.Dx()/2-w/2+w, b.Dy()-h)
+
+	scanner := rasterx.NewScannerGV(b.Dx(), b.Dy(), dst, b)
+	filler := rasterx.NewFiller(b.Dx(), b.Dy(), scanner)
+	r := float64(h) / 2
+	rasterx.AddRoundRect(float64(pill.Min.X), float64(pill.Min.Y), float64(pill.Max.X), float64(pill.Max.Y), r, r, 0, rasterx.RoundGap, filler)
+	scanner.SetColor(color.NRGBA{0, 0, 0, 0x60})
+	filler.Draw()
+
+	cy := float64(pill.Min.Y) + r
+	for i := range count {
+		filler.Clear()
+		cx := float64(pill.Min.X+carouselDotRadius*2) + float64(i*carouselDotSpacing)
+		rasterx.AddCircle(cx, cy, carouselDotRadius, filler)
+		if i == current {
+			scanner.SetColor(color.NRGBA{0xff, 0xff, 0xff, 0xff})
+		} else {
+			scanner.SetColor(color.NRGBA{0xff, 0xff, 0xff, 0x70})
+		}
+		filler.Draw()
+	}
+}
+
+// SaveSlides writes slides into dir as name_01.png, name_02.png and so on.
+func SaveSlides(dir fyne.URI, name string, slides []image.Image) error {
+	for i, img := range slides {
+		uri, err := storage.Child(dir, fmt.Sprintf("%s_%02d.png", name, i+1))
+		if err != nil {
+			return err
+		}
+		w, err := storage.Writer(uri)
+		if err != nil {
+			return err
+		}
+		err = encode(w, ".png", img)
+		w.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
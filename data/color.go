@@ -0,0 +1,486 @@
+package data
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"math"
+
+	"golang.org/x/image/draw"
+)
+
+type ColorSpace int
+
+const (
+	ColorSpaceSRGB ColorSpace = iota
+	ColorSpaceDisplayP3
+)
+
+var ColorSpaces = []ColorSpace{ColorSpaceSRGB, ColorSpaceDisplayP3}
+
+func (c ColorSpace) String() string {
+	switch c {
+	case ColorSpaceSRGB:
+		return "sRGB"
+	case ColorSpaceDisplayP3:
+		return "Display P3"
+	default:
+		return ""
+	}
+}
+
+// colorProfile is an RGB matrix/TRC profile: each channel is linearized by
+// its tone curve, then mapped to the D50 XYZ connection space by toXYZ.
+type colorProfile struct {
+	toXYZ [3][3]float64
+	trc   [3]toneCurve
+}
+
+var (
+	srgbProfile = colorProfile{
+		toXYZ: [3][3]float64{
+			{0.4360747, 0.3850649, 0.1430804},
+			{0.2225045, 0.7168786, 0.0606169},
+			{0.0139322, 0.0971045, 0.7141733},
+		},
+		trc: [3]toneCurve{srgbCurve, srgbCurve, srgbCurve},
+	}
+	displayP3Profile = colorProfile{
+		toXYZ: [3][3]float64{
+			{0.5151022, 0.2919654, 0.1571534},
+			{0.2411823, 0.6922360, 0.0665817},
+			{-0.0010500, 0.0418819, 0.7843778},
+		},
+		trc: [3]toneCurve{srgbCurve, srgbCurve, srgbCurve},
+	}
+)
+
+func (c ColorSpace) profile() colorProfile {
+	if c == ColorSpaceDisplayP3 {
+		return displayP3Profile
+	}
+	return srgbProfile
+}
+
+// toneCurve is an ICC parametric curve. Types 0 to 4 follow the ICC
+// specification; a non-nil table overrides the parameters.
+type toneCurve struct {
+	typ    int
+	params [7]float64
+	table  []float64
+}
+
+var srgbCurve = toneCurve{typ: 3, params: [7]float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045}}
+
+func (c toneCurve) linear(x float64) float64 {
+	if c.table != nil {
+		if len(c.table) == 1 {
+			return x
+		}
+		p := x * float64(len(c.table)-1)
+		i := min(int(p), len(c.table)-2)
+		f := p - float64(i)
+		return c.table[i]*(1-f) + c.table[i+1]*f
+	}
+	g, a, b, cc, d, e, f := c.params[0], c.params[1], c.params[2], c.params[3], c.params[4], c.params[5], c.params[6]
+	switch c.typ {
+	case 1:
+		if x >= -b/a {
+			return math.Pow(a*x+b, g)
+		}
+		return 0
+	case 2:
+		if x >= -b/a {
+			return math.Pow(a*x+b, g) + cc
+		}
+		return cc
+	case 3:
+		if x >= d {
+			return math.Pow(a*x+b, g)
+		}
+		return cc * x
+	case 4:
+		if x >= d {
+			return math.Pow(a*x+b, g) + e
+		}
+		return cc*x + f
+	default:
+		return math.Pow(x, g)
+	}
+}
+
+func srgbEncode(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func parseICC(data []byte) (colorProfile, bool) {
+	if len(data) < 132 || string(data[16:20]) != "RGB " || string(data[36:40]) != "acsp" {
+		return colorProfile{}, false
+	}
+	n := int(binary.BigEndian.Uint32(data[128:]))
+	tags := map[string][]byte{}
+	for i := 0; i < n; i++ {
+		p := 132 + i*12
+		if p+12 > len(data) {
+			return colorProfile{}, false
+		}
+		off, size := int(binary.BigEndian.Uint32(data[p+4:])), int(binary.BigEndian.Uint32(data[p+8:]))
+		if off+size <= len(data) {
+			tags[string(data[p:p+4])] = data[off : off+size]
+		}
+	}
+
+	var prof colorProfile
+	for i, name := range []string{"r", "g", "b"} {
+		xyz := tags[name+"XYZ"]
+		if len(xyz) < 20 || string(xyz[:4]) != "XYZ " {
+			return colorProfile{}, false
+		}
+		for j := range 3 {
+			prof.toXYZ[j][i] = s15Fixed16(xyz[8+j*4:])
+		}
+		curve, ok := parseCurve(tags[name+"TRC"])
+		if !ok {
+			return colorProfile{}, false
+		}
+		prof.trc[i] = curve
+	}
+	return prof, true
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func parseCurve(b []byte) (toneCurve, bool) {
+	if len(b) < 12 {
+		return toneCurve{}, false
+	}
+	switch string(b[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(b[8:]))
+		if len(b) < 12+n*2 {
+			return toneCurve{}, false
+		}
+		switch n {
+		case 0:
+			return toneCurve{params: [7]float64{1}}, true
+		case 1:
+			return toneCurve{params: [7]float64{float64(binary.BigEndian.Uint16(b[12:])) / 256}}, true
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(b[12+i*2:])) / 65535
+		}
+		return toneCurve{table: table}, true
+	case "para":
+		typ := int(binary.BigEndian.Uint16(b[8:]))
+		count := []int{1, 3, 4, 5, 7}
+		if typ >= len(count) || len(b) < 12+count[typ]*4 {
+			return toneCurve{}, false
+		}
+		c := toneCurve{typ: typ}
+		for i := range count[typ] {
+			c.params[i] = s15Fixed16(b[12+i*4:])
+		}
+		return c, true
+	default:
+		return toneCurve{}, false
+	}
+}
+
+func (p colorProfile) approximately(o colorProfile) bool {
+	for i := range 3 {
+		for j := range 3 {
+			if math.Abs(p.toXYZ[i][j]-o.toXYZ[i][j]) > 0.002 {
+				return false
+			}
+		}
+		for v := 0.0; v <= 1; v += 1.0 / 16 {
+			if math.Abs(p.trc[i].linear(v)-o.trc[i].linear(v)) > 0.002 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func invert3(m [3][3]float64) [3][3]float64 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	var r [3][3]float64
+	for i := range 3 {
+		for j := range 3 {
+			a, b := (j+1)%3, (j+2)%3
+			c, d := (i+1)%3, (i+2)%3
+			r[i][j] = (m[a][c]*m[b][d] - m[a][d]*m[b][c]) / det
+		}
+	}
+	return r
+}
+
+func mul3(a, b [3][3]float64) [3][3]float64 {
+	var r [3][3]float64
+	for i := range 3 {
+		for j := range 3 {
+			for k := range 3 {
+				r[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return r
+}
+
+const encodeTableSize = 4096
+
+// convertColor maps img from the src profile to the dst color space. Both
+// profiles are expected to share a D50 connection space. The result keeps
+// the bit depth of img, and stays grayscale if img is.
+func convertColor(img image.Image, src colorProfile, dst ColorSpace) image.Image {
+	target := dst.profile()
+	if src.approximately(target) {
+		return img
+	}
+	m := mul3(invert3(target.toXYZ), src.toXYZ)
+
+	b := img.Bounds()
+	format := formatOf(img)
+	var out draw.Image
+	if format.deep() {
+		out = convertDeep(img, src, m)
+	} else {
+		out = convertShallow(img, src, m)
+	}
+	if format.gray() {
+		gray := format.New(b)
+		draw.Draw(gray, b, out, b.Min, draw.Src)
+		return gray
+	}
+	return out
+}
+
+func convertShallow(img image.Image, src colorProfile, m [3][3]float64) *image.NRGBA {
+	var linear [3][256]float64
+	for c := range 3 {
+		for v := range 256 {
+			linear[c][v] = src.trc[c].linear(float64(v) / 255)
+		}
+	}
+	var encode [encodeTableSize + 1]uint8
+	for i := range encode {
+		encode[i] = uint8(math.Round(srgbEncode(float64(i)/encodeTableSize) * 255))
+	}
+
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	draw.Draw(out, b, img, b.Min, draw.Src)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := out.Pix[(y-b.Min.Y)*out.Stride:]
+		for x := 0; x < b.Dx(); x++ {
+			px := row[x*4 : x*4+3]
+			r, g, bl := linear[0][px[0]], linear[1][px[1]], linear[2][px[2]]
+			for c := range 3 {
+				v := m[c][0]*r + m[c][1]*g + m[c][2]*bl
+				px[c] = encode[int(math.Round(min(max(v, 0), 1)*encodeTableSize))]
+			}
+		}
+	}
+	return out
+}
+
+// convertDeep is convertShallow for 16 bits per channel, with tables at
+// full 16-bit resolution so no precision is lost on the way.
+func convertDeep(img image.Image, src colorProfile, m [3][3]float64) *image.NRGBA64 {
+	linear := make([][]float64, 3)
+	for c := range 3 {
+		linear[c] = make([]float64, 0x10000)
+		for v := range linear[c] {
+			linear[c][v] = src.trc[c].linear(float64(v) / 0xffff)
+		}
+	}
+	encode := make([]uint16, 0x10000)
+	for i := range encode {
+		encode[i] = uint16(math.Round(srgbEncode(float64(i)/0xffff) * 0xffff))
+	}
+
+	b := img.Bounds()
+	out := image.NewNRGBA64(b)
+	draw.Draw(out, b, img, b.Min, draw.Src)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := out.Pix[(y-b.Min.Y)*out.Stride:]
+		for x := 0; x < b.Dx(); x++ {
+			px := row[x*8 : x*8+6]
+			var in [3]float64
+			for c := range 3 {
+				in[c] = linear[c][uint16(px[c*2])<<8|uint16(px[c*2+1])]
+			}
+			for c := range 3 {
+				v := m[c][0]*in[0] + m[c][1]*in[1] + m[c][2]*in[2]
+				e := encode[int(math.Round(min(max(v, 0), 1)*0xffff))]
+				px[c*2], px[c*2+1] = uint8(e>>8), uint8(e)
+			}
+		}
+	}
+	return out
+}
+
+// normalize applies the EXIF orientation and converts the pixels from the
+// embedded ICC profile, if any, into the working color space.
+func normalize(img image.Image, m metadata, space ColorSpace) image.Image {
+	if m.exif != nil {
+		if x, err := parseExif(m.exif); err == nil {
+			img = orientation(x.Orientation).Apply(img)
+		}
+	}
+	src := srgbProfile
+	if m.profile != nil {
+		if p, ok := parseICC(m.profile); ok {
+			src = p
+		}
+	}
+	return convertColor(img, src, space)
+}
+
+func orientation(o int) Transform {
+	switch o {
+	case 2:
+		return Transform{FlipH: true}
+	case 3:
+		return Transform{Rotate: 2}
+	case 4:
+		return Transform{FlipV: true}
+	case 5:
+		return Transform{FlipV: true, Rotate: 1}
+	case 6:
+		return Transform{Rotate: 1}
+	case 7:
+		return Transform{FlipH: true, Rotate: 1}
+	case 8:
+		return Transform{Rotate: 3}
+	default:
+		return Transform{}
+	}
+}
+
+// iccProfile builds a minimal ICC v2 display profile describing the color
+// space, suitable for embedding in PNG and JPEG output.
+func (c ColorSpace) iccProfile() []byte {
+	p := c.profile()
+	type tag struct {
+		sig  string
+		data []byte
+	}
+
+	desc := func(s string) []byte {
+		var b bytes.Buffer
+		b.WriteString("desc\x00\x00\x00\x00")
+		binary.Write(&b, binary.BigEndian, uint32(len(s)+1))
+		b.WriteString(s)
+		b.WriteByte(0)
+		b.Write(make([]byte, 4+4+2+1+67))
+		return b.Bytes()
+	}
+	xyz := func(x, y, z float64) []byte {
+		var b bytes.Buffer
+		b.WriteString("XYZ \x00\x00\x00\x00")
+		for _, v := range []float64{x, y, z} {
+			binary.Write(&b, binary.BigEndian, int32(math.Round(v*65536)))
+		}
+		return b.Bytes()
+	}
+	curve := func(tc toneCurve) []byte {
+		const n = 1024
+		var b bytes.Buffer
+		b.WriteString("curv\x00\x00\x00\x00")
+		binary.Write(&b, binary.BigEndian, uint32(n))
+		for i := range n {
+			binary.Write(&b, binary.BigEndian, uint16(math.Round(tc.linear(float64(i)/(n-1))*65535)))
+		}
+		return b.Bytes()
+	}
+	text := func(s string) []byte {
+		return append([]byte("text\x00\x00\x00\x00"+s), 0)
+	}
+
+	trc := curve(p.trc[0])
+	tags := []tag{
+		{"desc", desc(c.String())},
+		{"cprt", text("No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1, 0.8249)},
+		{"rXYZ", xyz(p.toXYZ[0][0], p.toXYZ[1][0], p.toXYZ[2][0])},
+		{"gXYZ", xyz(p.toXYZ[0][1], p.toXYZ[1][1], p.toXYZ[2][1])},
+		{"bXYZ", xyz(p.toXYZ[0][2], p.toXYZ[1][2], p.toXYZ[2][2])},
+		{"rTRC", trc},
+		{"gTRC", trc},
+		{"bTRC", trc},
+	}
+
+	var body bytes.Buffer
+	var table bytes.Buffer
+	offset := 128 + 4 + len(tags)*12
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	for _, t := range tags {
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, uint32(offset+body.Len()))
+		binary.Write(&table, binary.BigEndian, uint32(len(t.data)))
+		body.Write(t.data)
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(offset+body.Len()))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+	binary.BigEndian.PutUint32(header[68:], uint32(int32(math.Round(0.9642*65536))))
+	binary.BigEndian.PutUint32(header[72:], 65536)
+	binary.BigEndian.PutUint32(header[76:], uint32(int32(math.Round(0.8249*65536))))
+	return bytes.Join([][]byte{header, table.Bytes(), body.Bytes()}, nil)
+}
+
+// embedProfile inserts an ICC profile into encoded PNG or JPEG data.
+func embedProfile(encoded []byte, ext string, profile []byte) []byte {
+	var out bytes.Buffer
+	switch ext {
+	case ".png":
+		const ihdrEnd = 8 + 8 + 13 + 4
+		if len(encoded) < ihdrEnd {
+			return encoded
+		}
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(profile)
+		zw.Close()
+		out.Write(encoded[:ihdrEnd])
+		writePNGChunk(&out, "iCCP", append([]byte("ICC Profile\x00\x00"), z.Bytes()...))
+		out.Write(encoded[ihdrEnd:])
+	case ".jpg", ".jpeg":
+		const maxChunk = 65535 - 2 - 14
+		if len(encoded) < 2 {
+			return encoded
+		}
+		out.Write(encoded[:2])
+		count := (len(profile) + maxChunk - 1) / maxChunk
+		for i := range count {
+			chunk := profile[i*maxChunk : min((i+1)*maxChunk, len(profile))]
+			out.Write([]byte{0xff, 0xe2})
+			binary.Write(&out, binary.BigEndian, uint16(2+len(iccJPEGPrefix)+2+len(chunk)))
+			out.Write(iccJPEGPrefix)
+			out.Write([]byte{byte(i + 1), byte(count)})
+			out.Write(chunk)
+		}
+		out.Write(encoded[2:])
+	default:
+		return encoded
+	}
+	return out.Bytes()
+}
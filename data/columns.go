@@ -0,0 +1,164 @@
+package data
+
+import (
+	"cmp"
+	"image"
+	"image/color"
+	"slices"
+
+	"golang.org/x/image/draw"
+)
+
+// Columns flows the merged image into Count side-by-side columns of
+// roughly equal height, separated by Gap pixels of Background. Columns
+// only break between items or at blank rows.
+type Columns struct {
+	Count      int
+	Gap        int
+	Background color.NRGBA
+}
+
+var DefaultColumns = Columns{Count: 1, Gap: 48, Background: color.NRGBA{0xff, 0xff, 0xff, 0xff}}
+
+func (c Columns) IsZero() bool {
+	return c.Count <= 1
+}
+
+// columnBreak is a place where a column may end at End and the next one
+// start at Next, skipping any separator or blank rows in between.
+type columnBreak struct {
+	End, Next int
+}
+
+// ColumnLayout is the result of flowing a merged image into columns. Each
+// segment is a range of merged rows shown in one column.
+type ColumnLayout struct {
+	Columns  Columns
+	Segments [][2]int
+	Width    int
+}
+
+func (l ImageList) ColumnLayout(merged image.Image) ColumnLayout {
+	columns, _ := l.Columns.Get()
+	b := merged.Bounds()
+	layout := ColumnLayout{Columns: columns, Width: b.Dx(), Segments: [][2]int{{0, b.Dy()}}}
+	if columns.IsZero() || b.Dy() == 0 {
+		return layout
+	}
+
+	var breaks []columnBreak
+	if mosaic, _ := l.Mosaic.Get(); !mosaic.active() {
+		rects := l.Layout()
+		for i := 1; i < len(rects); i++ {
+			breaks = append(breaks, columnBreak{rects[i-1].Max.Y, rects[i].Min.Y})
+		}
+	}
+	for y := 0; y < b.Dy(); y++ {
+		if !uniformRow(merged, b.Min.Y+y) {
+			continue
+		}
+		start := y
+		for y < b.Dy() && uniformRow(merged, b.Min.Y+y) {
+			y++
+		}
+		breaks = append(breaks, columnBreak{start, y})
+	}
+	slices.SortFunc(breaks, func(a, b columnBreak) int { return cmp.Compare(a.End, b.End) })
+
+	h := b.Dy()
+	lo, hi := (h+columns.Count-1)/columns.Count, h
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if flowColumns(breaks, h, mid, columns.Count) != nil {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	layout.Segments = flowColumns(breaks, h, lo, columns.Count)
+	return layout
+}
+
+// flowColumns fills each column up to height rows, ending it at the last
+// break that fits. It returns nil if count columns are not enough.
+func flowColumns(breaks []columnBreak, total, height, count int) [][2]int {
+	var segments [][2]int
+	start := 0
+	for range count {
+		if total-start <= height {
+			return append(segments, [2]int{start, total})
+		}
+		best := columnBreak{-1, -1}
+		for _, v := range breaks {
+			if v.End > start+height {
+				break
+			}
+			if v.End > start && v.Next > start {
+				best = v
+			}
+		}
+		if best.End < 0 {
+			return nil
+		}
+		segments = append(segments, [2]int{start, best.End})
+		start = best.Next
+	}
+	if start < total {
+		return nil
+	}
+	return segments
+}
+
+func (c ColumnLayout) Size() image.Point {
+	h := 0
+	for _, s := range c.Segments {
+		h = max(h, s[1]-s[0])
+	}
+	n := len(c.Segments)
+	return image.Pt(n*c.Width+(n-1)*c.Columns.Gap, h)
+}
+
+func (c ColumnLayout) Apply(img image.Image) image.Image {
+	if len(c.Segments) <= 1 {
+		return img
+	}
+	b := img.Bounds()
+	dst := formatOf(img).New(image.Rectangle{Max: c.Size()})
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(c.Columns.Background), image.Point{}, draw.Src)
+	for i, s := range c.Segments {
+		x := i * (c.Width + c.Columns.Gap)
+		draw.Draw(dst, image.Rect(x, 0, x+c.Width, s[1]-s[0]), img, b.Min.Add(image.Pt(0, s[0])), draw.Src)
+	}
+	return dst
+}
+
+// ToSheet maps a point of the merged image to the column sheet.
+func (c ColumnLayout) ToSheet(p image.Point) image.Point {
+	for i, s := range c.Segments {
+		if p.Y < s[1] || i == len(c.Segments)-1 {
+			return image.Pt(p.X+i*(c.Width+c.Columns.Gap), max(p.Y, s[0])-s[0])
+		}
+	}
+	return p
+}
+
+// FromSheet maps a point of the column sheet back to the merged image.
+func (c ColumnLayout) FromSheet(p image.Point) image.Point {
+	i := min(max(p.X/(c.Width+c.Columns.Gap), 0), len(c.Segments)-1)
+	s := c.Segments[i]
+	return image.Pt(p.X-i*(c.Width+c.Columns.Gap), min(p.Y+s[0], s[1]))
+}
+
+// SheetRects maps r of the merged image to the parts it occupies on the
+// column sheet.
+func (c ColumnLayout) SheetRects(r image.Rectangle) []image.Rectangle {
+	var rects []image.Rectangle
+	for i, s := range c.Segments {
+		part := r.Intersect(image.Rect(r.Min.X, s[0], r.Max.X, s[1]))
+		if part.Empty() {
+			continue
+		}
+		rects = append(rects, part.Add(image.Pt(i*(c.Width+c.Columns.Gap), -s[0])))
+	}
+	return rects
+}
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// textured returns an image without uniform rows, except for the rows in
+// blank, which are white.
+func textured(w, h int, blank ...[2]int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x * 40), uint8(y), 0x80, 0xff})
+		}
+	}
+	for _, v := range blank {
+		for y := v[0]; y < v[1]; y++ {
+			for x := range w {
+				img.SetNRGBA(x, y, color.NRGBA{0xff, 0xff, 0xff, 0xff})
+			}
+		}
+	}
+	return img
+}
+
+func TestFlowColumns(t *testing.T) {
+	tests := []struct {
+		name                 string
+		breaks               []columnBreak
+		total, height, count int
+		want                 [][2]int
+	}{
+		{"fits one column", nil, 100, 100, 2, [][2]int{{0, 100}}},
+		{"no breaks", nil, 100, 50, 2, nil},
+		{"too few columns", []columnBreak{{30, 30}, {60, 60}}, 90, 45, 2, nil},
+		{"even thirds", []columnBreak{{30, 30}, {60, 60}}, 90, 45, 3, [][2]int{{0, 30}, {30, 60}, {60, 90}}},
+		{"last break that fits", []columnBreak{{20, 20}, {40, 40}, {70, 70}}, 100, 60, 2, [][2]int{{0, 40}, {40, 100}}},
+		{"separator skipped", []columnBreak{{40, 64}}, 104, 60, 2, [][2]int{{0, 40}, {64, 104}}},
+		{"break at the start ignored", []columnBreak{{0, 10}, {50, 50}}, 100, 50, 2, [][2]int{{0, 50}, {50, 100}}},
+	}
+	for _, tt := range tests {
+		if got := flowColumns(tt.breaks, tt.total, tt.height, tt.count); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: flowColumns() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestColumnLayout(t *testing.T) {
+	gap := DefaultSeparator
+	gap.Style = SeparatorGap
+	tests := []struct {
+		name      string
+		images    []*image.NRGBA
+		count     int
+		separator Separator
+		want      [][2]int
+	}{
+		{"single column", []*image.NRGBA{textured(8, 40), textured(8, 40)}, 1, DefaultSeparator, [][2]int{{0, 80}}},
+		{"between items", []*image.NRGBA{textured(8, 40), textured(8, 40), textured(8, 40)}, 2, DefaultSeparator, [][2]int{{0, 80}, {80, 120}}},
+		{"separators skipped", []*image.NRGBA{textured(8, 40), textured(8, 40), textured(8, 40)}, 2, gap, [][2]int{{0, 104}, {128, 168}}},
+		{"blank rows", []*image.NRGBA{textured(8, 100, [2]int{45, 55})}, 2, DefaultSeparator, [][2]int{{0, 45}, {55, 100}}},
+		{"no break", []*image.NRGBA{textured(8, 100)}, 2, DefaultSeparator, [][2]int{{0, 100}}},
+	}
+	for _, tt := range tests {
+		l := NewImageList()
+		for _, v := range tt.images {
+			l.Append(NewImage(nil, v))
+		}
+		l.Separator.Set(tt.separator)
+		columns := DefaultColumns
+		columns.Count = tt.count
+		l.Columns.Set(columns)
+		if got := l.ColumnLayout(l.Compose()).Segments; !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Segments = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestColumnLayoutSheet(t *testing.T) {
+	c := ColumnLayout{Columns: Columns{Count: 2, Gap: 10}, Segments: [][2]int{{0, 80}, {80, 120}}, Width: 100}
+	if got, want := c.Size(), image.Pt(210, 80); got != want {
+		t.Errorf("Size() = %v, want %v", got, want)
+	}
+
+	points := []struct {
+		merged, sheet image.Point
+	}{
+		{image.Pt(5, 10), image.Pt(5, 10)},
+		{image.Pt(5, 79), image.Pt(5, 79)},
+		{image.Pt(5, 80), image.Pt(115, 0)},
+		{image.Pt(99, 119), image.Pt(209, 39)},
+	}
+	for _, v := range points {
+		if got := c.ToSheet(v.merged); got != v.sheet {
+			t.Errorf("ToSheet(%v) = %v, want %v", v.merged, got, v.sheet)
+		}
+		if got := c.FromSheet(v.sheet); got != v.merged {
+			t.Errorf("FromSheet(%v) = %v, want %v", v.sheet, got, v.merged)
+		}
+	}
+	// Points below the last row stay in the last column.
+	if got, want := c.ToSheet(image.Pt(5, 130)), image.Pt(115, 50); got != want {
+		t.Errorf("ToSheet() past the end = %v, want %v", got, want)
+	}
+
+	got := c.SheetRects(image.Rect(0, 70, 100, 90))
+	want := []image.Rectangle{image.Rect(0, 70, 100, 80), image.Rect(110, 0, 210, 10)}
+	if !slices.Equal(got, want) {
+		t.Errorf("SheetRects() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,248 @@
+package data
+
+import (
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"golang.org/x/image/draw"
+)
+
+// Comparison puts two lists side by side under their own label headers.
+// Rows the two composed images have in common are lined up, and rows only
+// one side has are padded with Missing on the other. With Heatmap, a third
+// column shows how much each pixel of the aligned rows differs.
+type Comparison struct {
+	BeforeLabel, AfterLabel string
+	Heatmap                 bool
+	Gap                     int
+	Background              color.NRGBA
+	Missing                 color.NRGBA
+}
+
+var DefaultComparison = Comparison{
+	BeforeLabel: "Before",
+	AfterLabel:  "After",
+	Heatmap:     true,
+	Gap:         24,
+	Background:  color.NRGBA{0xff, 0xff, 0xff, 0xff},
+	Missing:     color.NRGBA{0xe8, 0xe8, 0xe8, 0xff},
+}
+
+const (
+	comparisonTextSize = 28
+	comparisonPadding  = 16
+)
+
+// Render exports both lists and lays them out as aligned columns.
+func (c Comparison) Render(before, after ImageList) image.Image {
+	a, b := toRGBA(before.Export()), toRGBA(after.Export())
+	rows := alignRows(rowHashes(a), rowHashes(b))
+
+	wa, wb := a.Bounds().Dx(), b.Bounds().Dx()
+	labels := []string{c.BeforeLabel, c.AfterLabel}
+	widths := []int{wa, wb}
+	if c.Heatmap {
+		labels = append(labels, "Difference")
+		widths = append(widths, max(wa, wb))
+	}
+	header := 0
+	for _, v := range labels {
+		_, h := measureText(v, comparisonTextSize)
+		header = max(header, h+comparisonPadding*2)
+	}
+
+	w := (len(widths) - 1) * c.Gap
+	for _, v := range widths {
+		w += v
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, header+len(rows)))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(c.Background), image.Point{}, draw.Src)
+
+	x := 0
+	for i, v := range labels {
+		tw, _ := measureText(v, comparisonTextSize)
+		drawText(dst, v, comparisonTextSize, color.Black, image.Pt(x+max(widths[i]-tw, 0)/2, comparisonPadding))
+		x += widths[i] + c.Gap
+	}
+
+	c.drawSide(dst, a, rows, 0, image.Pt(0, header))
+	c.drawSide(dst, b, rows, 1, image.Pt(wa+c.Gap, header))
+	if c.Heatmap {
+		drawHeatmap(dst, a, b, rows, image.Pt(wa+wb+c.Gap*2, header))
+	}
+	return dst
+}
+
+// drawSide copies the rows of src picked by side of each aligned pair,
+// in runs of consecutive rows, and fills the gaps with Missing.
+func (c Comparison) drawSide(dst *image.RGBA, src *image.RGBA, rows [][2]int, side int, at image.Point) {
+	w := src.Bounds().Dx()
+	for y := 0; y < len(rows); {
+		start, v := y, rows[y][side]
+		for y++; y < len(rows); y++ {
+			next := rows[y][side]
+			if v < 0 && next >= 0 || v >= 0 && next != v+y-start {
+				break
+			}
+		}
+		r := image.Rect(at.X, at.Y+start, at.X+w, at.Y+y)
+		if v < 0 {
+			draw.Draw(dst, r, image.NewUniform(c.Missing), image.Point{}, draw.Src)
+		} else {
+			draw.Draw(dst, r, src, image.Pt(0, v), draw.Src)
+		}
+	}
+}
+
+func drawHeatmap(dst, a, b *image.RGBA, rows [][2]int, at image.Point) {
+	w := max(a.Bounds().Dx(), b.Bounds().Dx())
+	for y, v := range rows {
+		for x := range w {
+			pa, okA := rowPixel(a, x, v[0])
+			pb, okB := rowPixel(b, x, v[1])
+			var d uint8
+			if okA != okB {
+				d = 0xff
+			} else if okA {
+				d = max(diff8(pa[0], pb[0]), diff8(pa[1], pb[1]), diff8(pa[2], pb[2]), diff8(pa[3], pb[3]))
+			}
+			p := dst.Pix[dst.PixOffset(at.X+x, at.Y+y):]
+			if d == 0 {
+				// Unchanged pixels are a faded grayscale of the after side,
+				// so the heat stands out against recognizable content.
+				var luma uint8 = 0xff
+				if okB {
+					luma = uint8((299*int(pb[0]) + 587*int(pb[1]) + 114*int(pb[2])) / 1000)
+				}
+				g := 0xff - (0xff-luma)/4
+				p[0], p[1], p[2], p[3] = g, g, g, 0xff
+				continue
+			}
+			t := min(int(d)*4, 0xff)
+			p[0], p[1], p[2], p[3] = 0xff, uint8(0xd0*(0xff-t)/0xff), 0, 0xff
+		}
+	}
+}
+
+func rowPixel(img *image.RGBA, x, y int) ([]uint8, bool) {
+	b := img.Bounds()
+	if y < 0 || x >= b.Dx() {
+		return nil, false
+	}
+	return img.Pix[img.PixOffset(b.Min.X+x, b.Min.Y+y):][:4], true
+}
+
+func diff8(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// alignRows pairs up the rows of a and b so that matching rows line up.
+// Rows that appear exactly once on each side serve as anchors; the longest
+// run of anchors in the same order on both sides is kept, and the rows
+// between them are matched from both ends before being paired by position.
+// A side is -1 where the other side has a row of its own.
+func alignRows(a, b []uint64) [][2]int {
+	ia, ib := indexRows(a), indexRows(b)
+	var anchors [][2]int
+	for y, h := range a {
+		if len(ia[h]) == 1 && len(ib[h]) == 1 {
+			anchors = append(anchors, [2]int{y, ib[h][0]})
+		}
+	}
+	anchors = increasingAnchors(anchors)
+
+	var rows [][2]int
+	pa, pb := 0, 0
+	for _, v := range append(anchors, [2]int{len(a), len(b)}) {
+		rows = append(rows, alignGap(a, b, pa, v[0], pb, v[1])...)
+		if v[0] < len(a) {
+			rows = append(rows, v)
+		}
+		pa, pb = v[0]+1, v[1]+1
+	}
+	return rows
+}
+
+// increasingAnchors returns the longest subsequence of anchors, which are
+// sorted by their first row, whose second rows are increasing as well.
+func increasingAnchors(anchors [][2]int) [][2]int {
+	var tails []int
+	prev := make([]int, len(anchors))
+	for i, v := range anchors {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if anchors[tails[mid]][1] < v[1] {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	kept := make([][2]int, len(tails))
+	if len(tails) == 0 {
+		return kept
+	}
+	for i, j := len(tails)-1, tails[len(tails)-1]; i >= 0; i, j = i-1, prev[j] {
+		kept[i] = anchors[j]
+	}
+	return kept
+}
+
+func alignGap(a, b []uint64, a0, a1, b0, b1 int) [][2]int {
+	var head, tail [][2]int
+	for a0 < a1 && b0 < b1 && a[a0] == b[b0] {
+		head = append(head, [2]int{a0, b0})
+		a0, b0 = a0+1, b0+1
+	}
+	for a0 < a1 && b0 < b1 && a[a1-1] == b[b1-1] {
+		a1, b1 = a1-1, b1-1
+		tail = append(tail, [2]int{a1, b1})
+	}
+	for i := 0; a0+i < a1 || b0+i < b1; i++ {
+		v := [2]int{-1, -1}
+		if a0+i < a1 {
+			v[0] = a0 + i
+		}
+		if b0+i < b1 {
+			v[1] = b0 + i
+		}
+		head = append(head, v)
+	}
+	for i := len(tail) - 1; i >= 0; i-- {
+		head = append(head, tail[i])
+	}
+	return head
+}
+
+// Save renders the comparison and encodes it by the writer's extension.
+func (c Comparison) Save(writer fyne.URIWriteCloser, before, after ImageList) error {
+	ext := writer.URI().Extension()
+	if ext == ".pdf" || !isEncodable(ext) {
+		return ErrUnsupportedExtension
+	}
+	return encode(writer, ext, c.Render(before, after))
+}
@@ -0,0 +1,55 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAlignRows(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []uint64
+		want [][2]int
+	}{
+		{"identical", []uint64{1, 2, 3}, []uint64{1, 2, 3}, [][2]int{{0, 0}, {1, 1}, {2, 2}}},
+		{"inserted", []uint64{1, 2, 3, 4}, []uint64{1, 2, 9, 3, 4}, [][2]int{{0, 0}, {1, 1}, {-1, 2}, {2, 3}, {3, 4}}},
+		{"deleted", []uint64{1, 2, 9, 3, 4}, []uint64{1, 2, 3, 4}, [][2]int{{0, 0}, {1, 1}, {2, -1}, {3, 2}, {4, 3}}},
+		{"changed", []uint64{1, 5, 3}, []uint64{1, 6, 3}, [][2]int{{0, 0}, {1, 1}, {2, 2}}},
+		{"appended", []uint64{1, 2}, []uint64{1, 2, 3, 4}, [][2]int{{0, 0}, {1, 1}, {-1, 2}, {-1, 3}}},
+		{"reordered", []uint64{1, 2, 3, 4, 5, 6}, []uint64{4, 5, 6, 1, 2, 3}, [][2]int{
+			{0, -1}, {1, -1}, {2, -1}, {3, 0}, {4, 1}, {5, 2}, {-1, 3}, {-1, 4}, {-1, 5},
+		}},
+		{"repeated rows", []uint64{1, 7, 7, 2}, []uint64{1, 7, 7, 7, 2}, [][2]int{{0, 0}, {1, 1}, {2, 2}, {-1, 3}, {3, 4}}},
+		{"empty side", nil, []uint64{1, 2}, [][2]int{{-1, 0}, {-1, 1}}},
+	}
+	for _, tt := range tests {
+		if got := alignRows(tt.a, tt.b); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: alignRows() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIncreasingAnchors(t *testing.T) {
+	tests := []struct {
+		anchors, want [][2]int
+	}{
+		{nil, [][2]int{}},
+		{[][2]int{{0, 0}, {1, 1}, {2, 2}}, [][2]int{{0, 0}, {1, 1}, {2, 2}}},
+		{[][2]int{{0, 2}, {1, 0}, {2, 1}, {3, 3}}, [][2]int{{1, 0}, {2, 1}, {3, 3}}},
+		{[][2]int{{0, 5}, {1, 1}, {2, 4}, {3, 2}, {4, 3}}, [][2]int{{1, 1}, {3, 2}, {4, 3}}},
+	}
+	for _, tt := range tests {
+		if got := increasingAnchors(tt.anchors); !slices.Equal(got, tt.want) {
+			t.Errorf("increasingAnchors(%v) = %v, want %v", tt.anchors, got, tt.want)
+		}
+	}
+}
+
+func TestAlignGap(t *testing.T) {
+	a := []uint64{1, 2, 3, 4, 5}
+	b := []uint64{1, 2, 8, 9, 4, 5}
+	want := [][2]int{{0, 0}, {1, 1}, {2, 2}, {-1, 3}, {3, 4}, {4, 5}}
+	if got := alignGap(a, b, 0, len(a), 0, len(b)); !slices.Equal(got, want) {
+		t.Errorf("alignGap() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"image"
+
+	"fyne.io/fyne/v2/data/binding"
+	"github.com/yukkie8058/rollshot/bindingx"
+)
+
+// MinCropSize is the smallest width and height a crop can be reduced to,
+// unless the image itself is smaller.
+const MinCropSize = 8
+
+// Crop is the region of an image that is kept when merging. Set clamps the
+// rectangle so that it always lies within the image bounds and is at least
+// MinCropSize wide and high. When edges would cross, the edges that moved
+// are held back; the edges that did not move stay in place.
+type Crop interface {
+	bindingx.Typed[image.Rectangle]
+
+	Reset() error
+}
+
+type crop struct {
+	bindingx.Typed[image.Rectangle]
+	image *Image
+}
+
+func (c *crop) Set(r image.Rectangle) error {
+	old, _ := c.Typed.Get()
+	b := c.image.Bounds()
+	r.Min.X, r.Max.X = clampSpan(old.Min.X, old.Max.X, r.Min.X, r.Max.X, b.Min.X, b.Max.X)
+	r.Min.Y, r.Max.Y = clampSpan(old.Min.Y, old.Max.Y, r.Min.Y, r.Max.Y, b.Min.Y, b.Max.Y)
+	if r == old {
+		return nil
+	}
+	return c.Typed.Set(r)
+}
+
+func (c *crop) Reset() error {
+	return c.Set(c.image.Bounds())
+}
+
+func clampSpan(oldLo, oldHi, lo, hi, minLo, maxHi int) (int, int) {
+	size := min(MinCropSize, maxHi-minLo)
+	lo = max(minLo, min(lo, maxHi-size))
+	hi = max(minLo+size, min(hi, maxHi))
+	if hi-lo >= size {
+		return lo, hi
+	}
+	if lo != oldLo && hi == oldHi {
+		return hi - size, hi
+	}
+	return lo, lo + size
+}
+
+type trimDirection int
+
+const (
+	trimLeading trimDirection = iota
+	trimTrailing
+)
+
+type trim struct {
+	crop      *crop
+	direction trimDirection
+}
+
+func (t trim) AddListener(l binding.DataListener)    { t.crop.AddListener(l) }
+func (t trim) RemoveListener(l binding.DataListener) { t.crop.RemoveListener(l) }
+
+func (t trim) Get() (int, error) {
+	r, err := t.crop.Get()
+	b := t.crop.image.Bounds()
+	switch t.direction {
+	case trimLeading:
+		return r.Min.Y - b.Min.Y, err
+	default:
+		return b.Max.Y - r.Max.Y, err
+	}
+}
+
+func (t trim) Set(val int) error {
+	r, _ := t.crop.Get()
+	b := t.crop.image.Bounds()
+	switch t.direction {
+	case trimLeading:
+		r.Min.Y = b.Min.Y + val
+	case trimTrailing:
+		r.Max.Y = b.Max.Y - val
+	}
+	return t.crop.Set(r)
+}
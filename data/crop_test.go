@@ -0,0 +1,57 @@
+package data
+
+import (
+	"image"
+	"testing"
+)
+
+func TestClampSpan(t *testing.T) {
+	tests := []struct {
+		name         string
+		oldLo, oldHi int
+		lo, hi       int
+		minLo, maxHi int
+		want         [2]int
+	}{
+		{"inside", 0, 100, 10, 90, 0, 100, [2]int{10, 90}},
+		{"past both ends", 0, 100, -20, 140, 0, 100, [2]int{0, 100}},
+		{"low edge crosses high", 0, 100, 97, 100, 0, 100, [2]int{92, 100}},
+		{"high edge crosses low", 0, 100, 0, 3, 0, 100, [2]int{0, 8}},
+		{"low edge past the end", 0, 100, 120, 100, 0, 100, [2]int{92, 100}},
+		{"high edge before the start", 0, 100, 0, -10, 0, 100, [2]int{0, 8}},
+		{"both edges collapse", 10, 90, 50, 50, 0, 100, [2]int{50, 58}},
+		{"span smaller than the minimum", 0, 5, 2, 3, 0, 5, [2]int{0, 5}},
+		{"offset bounds", 20, 60, 10, 70, 20, 60, [2]int{20, 60}},
+	}
+	for _, tt := range tests {
+		lo, hi := clampSpan(tt.oldLo, tt.oldHi, tt.lo, tt.hi, tt.minLo, tt.maxHi)
+		if got := [2]int{lo, hi}; got != tt.want {
+			t.Errorf("%s: clampSpan() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCropSet(t *testing.T) {
+	img := NewImage(nil, image.NewRGBA(image.Rect(0, 0, 50, 200)))
+	tests := []struct {
+		set, want image.Rectangle
+	}{
+		{image.Rect(5, 10, 45, 190), image.Rect(5, 10, 45, 190)},
+		{image.Rect(-5, -10, 60, 300), image.Rect(0, 0, 50, 200)},
+		{image.Rect(0, 198, 50, 200), image.Rect(0, 192, 50, 200)},
+		{image.Rectangle{image.Pt(0, 192), image.Pt(50, 100)}, image.Rect(0, 192, 50, 200)},
+		{image.Rect(48, 0, 50, 200), image.Rect(42, 0, 50, 200)},
+	}
+	for _, tt := range tests {
+		if err := img.Crop.Set(tt.set); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := img.Crop.Get(); got != tt.want {
+			t.Errorf("Set(%v) gives %v, want %v", tt.set, got, tt.want)
+		}
+	}
+	img.Crop.Reset()
+	if got, _ := img.Crop.Get(); got != img.Bounds() {
+		t.Errorf("Reset() gives %v, want %v", got, img.Bounds())
+	}
+}
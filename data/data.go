@@ -1,10 +1,13 @@
 package data
 
 import (
+	"bytes"
 	"errors"
 	"image"
 	"image/jpeg"
 	"image/png"
+	"io"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/data/binding"
@@ -16,44 +19,268 @@ import (
 
 type ImageList struct {
 	bindingx.TypedList[*Image]
+
+	Annotations bindingx.TypedList[*Annotation]
+	Separator   bindingx.Typed[Separator]
+	Decoration  bindingx.Typed[Decoration]
+	Columns     bindingx.Typed[Columns]
+	Mosaic      bindingx.Typed[Mosaic]
+	Stamp       bindingx.Typed[Stamp]
+	Output      bindingx.Typed[Output]
+	ColorSpace  bindingx.Typed[ColorSpace]
+
+	history *history
+	mosaic  *mosaicCache
 }
 
 func NewImageList() ImageList {
-	return ImageList{bindingx.NewTypedList[*Image]()}
+	l := ImageList{
+		TypedList:   bindingx.NewTypedList[*Image](),
+		Annotations: bindingx.NewTypedList[*Annotation](),
+		Separator:   bindingx.NewTyped[Separator](),
+		Decoration:  bindingx.NewTyped[Decoration](),
+		Columns:     bindingx.NewTyped[Columns](),
+		Mosaic:      bindingx.NewTyped[Mosaic](),
+		Stamp:       bindingx.NewTyped[Stamp](),
+		Output:      bindingx.NewTyped[Output](),
+		ColorSpace:  bindingx.NewTyped[ColorSpace](),
+		history:     &history{},
+		mosaic:      &mosaicCache{},
+	}
+	l.Separator.Set(DefaultSeparator)
+	l.Decoration.Set(DefaultDecoration)
+	l.Columns.Set(DefaultColumns)
+	l.Mosaic.Set(DefaultMosaic)
+	l.Stamp.Set(DefaultStamp)
+	l.Output.Set(DefaultOutput)
+	l.ColorSpace.Set(ColorSpaceSRGB)
+	return l
 }
 
-var ErrUnsupportedExtension = errors.New("unsupported extension")
+var (
+	ErrUnsupportedExtension = errors.New("unsupported extension")
+	ErrEmptyList            = errors.New("there are no images to save")
+)
 
+// Save writes the exported image to writer.
 func (l ImageList) Save(writer fyne.URIWriteCloser) error {
-	switch writer.URI().Extension() {
+	_, err := l.SaveWithReport(writer)
+	return err
+}
+
+// SaveWithReport is Save that also returns what PNG optimization achieved.
+// The report is only filled in when optimization ran.
+func (l ImageList) SaveWithReport(writer fyne.URIWriteCloser) (OptimizeReport, error) {
+	ext := writer.URI().Extension()
+	if !isEncodable(ext) {
+		return OptimizeReport{}, ErrUnsupportedExtension
+	}
+	if l.Length() == 0 {
+		return OptimizeReport{}, ErrEmptyList
+	}
+	if ext == ".pdf" {
+		name := writer.URI().Name()
+		return OptimizeReport{}, l.WritePDF(writer, strings.TrimSuffix(name, ext))
+	}
+	output, _ := l.Output.Get()
+	render, columns := l.render()
+	stamped := l.stamp(render)
+	var img, retina image.Image
+	if size := stamped.Bounds().Size(); output.Retina {
+		// The @2x image is the sharp one; the saved image is scaled down
+		// from it.
+		retina = output.resize(stamped, output.RetinaSize(size))
+		img = output.resize(retina, output.Size(size))
+	} else {
+		img = output.Apply(stamped)
+	}
+	report, err := l.encode(writer, ext, img)
+	if err != nil {
+		return report, err
+	}
+	if output.LayoutMap {
+		if err := l.writeLayoutMap(writer.URI(), columns); err != nil {
+			return report, err
+		}
+	}
+	if retina == nil {
+		return report, nil
+	}
+
+	uri, err := retinaURI(writer.URI())
+	if err != nil {
+		return report, err
+	}
+	w, err := storage.Writer(uri)
+	if err != nil {
+		return report, err
+	}
+	defer w.Close()
+	_, err = l.encode(w, ext, retina)
+	return report, err
+}
+
+func (l ImageList) encode(w io.Writer, ext string, img image.Image) (OptimizeReport, error) {
+	output, _ := l.Output.Get()
+	var report OptimizeReport
+	var b []byte
+	if ext == ".png" && output.Optimize.Enabled {
+		var err error
+		if b, report, err = optimizePNG(img, output.Optimize, !output.EmbedProfile); err != nil {
+			return report, err
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := encode(&buf, ext, img); err != nil {
+			return report, err
+		}
+		b = buf.Bytes()
+	}
+	if output.EmbedProfile {
+		space, _ := l.ColorSpace.Get()
+		b = embedProfile(b, ext, space.iccProfile())
+	}
+	_, err := w.Write(b)
+	return report, err
+}
+
+func isEncodable(ext string) bool {
+	switch ext {
+	case ".jpg", ".jpeg", ".png", ".pdf":
+		return true
+	default:
+		return false
+	}
+}
+
+func encode(w io.Writer, ext string, img image.Image) error {
+	switch ext {
 	case ".jpg", ".jpeg":
-		return jpeg.Encode(writer, l.Merge(), nil)
+		return jpeg.Encode(w, img, nil)
 	case ".png":
-		return png.Encode(writer, l.Merge())
+		return png.Encode(w, img)
 	default:
 		return ErrUnsupportedExtension
 	}
 }
 
+// Export renders the list the way every export sees it: decorated,
+// arranged in columns, stamped and scaled to the output size.
+func (l ImageList) Export() image.Image {
+	render, _ := l.render()
+	output, _ := l.Output.Get()
+	return output.Apply(l.stamp(render))
+}
+
+// stamp is applied before output scaling, so the watermark and footer keep
+// their size relative to the content at every output size.
+func (l ImageList) stamp(render image.Image) image.Image {
+	stamp, _ := l.Stamp.Get()
+	return stamp.Apply(render)
+}
+
+// exportsMerge reports whether Export returns the merged image of the
+// given size unchanged, so it can be produced in strips instead.
+func (l ImageList) exportsMerge(size image.Point) bool {
+	decoration, _ := l.Decoration.Get()
+	columns, _ := l.Columns.Get()
+	stamp, _ := l.Stamp.Get()
+	output, _ := l.Output.Get()
+	return decoration.IsZero() && columns.IsZero() &&
+		!stamp.Watermark.Enabled && !stamp.Footer.Enabled &&
+		output.Size(size) == size
+}
+
+func (l ImageList) Render() image.Image {
+	render, _ := l.render()
+	return render
+}
+
+// render composes the list once and also returns the column layout it was
+// arranged with, or nil when columns are off.
+func (l ImageList) render() (image.Image, *ColumnLayout) {
+	composed := l.Compose()
+	columns := l.columnLayout(composed)
+	img := composed
+	if columns != nil {
+		img = columns.Apply(composed)
+	}
+	decoration, _ := l.Decoration.Get()
+	return decoration.Apply(img), columns
+}
+
+// Arrange flows the composed image into columns if they are enabled.
+func (l ImageList) Arrange(composed image.Image) image.Image {
+	if columns := l.columnLayout(composed); columns != nil {
+		return columns.Apply(composed)
+	}
+	return composed
+}
+
+func (l ImageList) columnLayout(composed image.Image) *ColumnLayout {
+	if columns, _ := l.Columns.Get(); columns.IsZero() {
+		return nil
+	}
+	layout := l.ColumnLayout(composed)
+	return &layout
+}
+
+func (l ImageList) Compose() image.Image {
+	img := l.Merge()
+	if dst, ok := img.(draw.Image); ok {
+		annotations, _ := l.Annotations.Get()
+		DrawAnnotations(dst, annotations, image.Point{}, 1)
+	}
+	return img
+}
+
 func (l ImageList) Merge() image.Image {
-	images := make([]image.Image, l.Length())
+	m := l.merger()
+	return m.strip(image.Rectangle{Max: m.size})
+}
+
+// merger renders the merged image, or any horizontal strip of it, without
+// holding the whole result in memory.
+type merger struct {
+	list   []*Image
+	rects  []image.Rectangle
+	images []image.Image
+	format pixelFormat
+	size   image.Point
+	mosaic bool
+	l      ImageList
+}
+
+func (l ImageList) merger() merger {
 	list, _ := l.Get()
+	mosaic, _ := l.Mosaic.Get()
+	m := merger{list: list, rects: l.Layout(), images: make([]image.Image, len(list)), mosaic: mosaic.active(), l: l}
+	for _, r := range m.rects {
+		m.size.X = max(m.size.X, r.Max.X)
+		m.size.Y = max(m.size.Y, r.Max.Y)
+	}
 	for i, v := range list {
-		images[i] = v.Trim()
+		m.images[i] = v.Trim()
+	}
+	m.format = mergeFormat(m.images)
+	if l.Annotations.Length() > 0 {
+		m.format = m.format.withAlpha()
 	}
+	return m
+}
 
-	w, h := 0, 0
-	for _, v := range images {
-		if v.Bounds().Dx() > w {
-			w = v.Bounds().Dx()
+func (m merger) strip(r image.Rectangle) draw.Image {
+	dst := m.format.New(r)
+	for i, img := range m.images {
+		if i > 0 && !m.mosaic {
+			sep := image.Rect(0, m.rects[i-1].Max.Y, m.size.X, m.rects[i].Min.Y)
+			if sep.Overlaps(r) {
+				m.l.SeparatorAfter(m.list[i-1]).Draw(dst, sep)
+			}
+		}
+		if m.rects[i].Overlaps(r) {
+			draw.Draw(dst, m.rects[i], img, img.Bounds().Min, draw.Src)
 		}
-		h += v.Bounds().Dy()
-	}
-	dst := image.NewRGBA(image.Rect(0, 0, w, h))
-	y := 0
-	for _, v := range images {
-		draw.Draw(dst, dst.Bounds().Add(image.Pt(0, y)), v, v.Bounds().Min, draw.Src)
-		y += v.Bounds().Dy()
 	}
 	return dst
 }
@@ -62,77 +289,67 @@ type Image struct {
 	URI   fyne.URI
 	Image image.Image
 
+	Crop                      Crop
 	TrimLeading, TrimTrailing binding.Int
+	Redactions                bindingx.TypedList[*Redaction]
+	Separator                 bindingx.Typed[*Separator]
+	Generated                 *Generated
+	Frame                     int
+	Transform                 bindingx.Typed[Transform]
+
+	transformed *transformed
+
+	phash *phashCache
+}
+
+func NewImage(uri fyne.URI, img image.Image) *Image {
+	i := &Image{
+		URI:         uri,
+		Image:       img,
+		Redactions:  bindingx.NewTypedList[*Redaction](),
+		Separator:   bindingx.NewTyped[*Separator](),
+		Transform:   bindingx.NewTyped[Transform](),
+		transformed: &transformed{},
+		phash:       &phashCache{},
+	}
+	i.Separator.Set(nil)
+	i.Transform.Set(Transform{})
+	c := &crop{bindingx.NewTyped[image.Rectangle](), i}
+	c.Typed.Set(img.Bounds())
+	i.Crop = c
+	i.TrimLeading = trim{c, trimLeading}
+	i.TrimTrailing = trim{c, trimTrailing}
+	return i
 }
 
-func LoadImage(uri fyne.URI) (*Image, error) {
-	i := &Image{URI: uri}
+func LoadImage(uri fyne.URI, space ColorSpace) (*Image, error) {
 	r, err := storage.Reader(uri)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Close()
-	i.Image, _, err = image.Decode(r)
+	b, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
-
-	i.TrimLeading = trim{binding.NewInt(), i, trimLeading}
-	i.TrimTrailing = trim{binding.NewInt(), i, trimTrailing}
-	return i, nil
+	img, _, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	m, _ := readMetadata(bytes.NewReader(b))
+	return NewImage(uri, normalize(img, m, space)), nil
 }
 
 func (i Image) Trim() image.Image {
 	type subImager interface {
 		SubImage(r image.Rectangle) image.Image
 	}
-	tl, _ := i.TrimLeading.Get()
-	tt, _ := i.TrimTrailing.Get()
-	return i.Image.(subImager).SubImage(image.Rectangle{
-		i.Image.Bounds().Min.Add(image.Point{0, tl}),
-		i.Image.Bounds().Max.Sub(image.Point{0, tt}),
-	})
-}
-
-type trimDirection int
-
-const (
-	trimLeading trimDirection = iota
-	trimTrailing
-)
-
-type trim struct {
-	binding.Int
-	image *Image
-
-	direction trimDirection
-}
-
-func (t trim) Set(val int) error {
-	h := t.image.Image.Bounds().Dy()
-
-	if val < 0 {
-		val = 0
-	} else if val > h {
-		val = h
-	}
-
-	switch t.direction {
-	case trimLeading:
-		if t.image.TrimTrailing != nil {
-			tt, _ := t.image.TrimTrailing.Get()
-			if val > h-tt {
-				t.image.TrimTrailing.Set(h - val - 1)
-			}
-		}
-	case trimTrailing:
-		if t.image.TrimLeading != nil {
-			tl, _ := t.image.TrimLeading.Get()
-			if val > h-tl {
-				t.image.TrimLeading.Set(h - val - 1)
-			}
-		}
+	r, _ := i.Crop.Get()
+	src := i.Redacted()
+	if s, ok := src.(subImager); ok {
+		return s.SubImage(r)
 	}
-
-	return t.Int.Set(val)
+	dst := formatOf(src).New(r)
+	draw.Draw(dst, r, src, r.Min, draw.Src)
+	return dst
 }
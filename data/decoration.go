@@ -0,0 +1,230 @@
+package data
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/fixed"
+)
+
+// Decoration describes the frame drawn around the merged image. The
+// content is placed at least Padding pixels from each edge of the output,
+// further where the shadow needs room, and the output is filled with
+// Background, or a vertical gradient to Background2.
+type Decoration struct {
+	Padding     int
+	Background  color.NRGBA
+	Gradient    bool
+	Background2 color.NRGBA
+
+	CornerRadius int
+
+	ShadowRadius int
+	ShadowOffset int
+	ShadowColor  color.NRGBA
+
+	BorderWidth int
+	BorderColor color.NRGBA
+}
+
+var DefaultDecoration = Decoration{
+	Background:  color.NRGBA{0xff, 0xff, 0xff, 0xff},
+	Background2: color.NRGBA{0xd0, 0xd8, 0xe8, 0xff},
+	ShadowColor: color.NRGBA{0, 0, 0, 0x80},
+	BorderColor: color.NRGBA{0, 0, 0, 0x40},
+}
+
+func (d Decoration) IsZero() bool {
+	return d.Padding == 0 && d.CornerRadius == 0 && d.ShadowRadius == 0 && d.BorderWidth == 0
+}
+
+// shadowBlurPasses is the number of box blurs approximating a Gaussian
+// shadow.
+const shadowBlurPasses = 3
+
+// shadowSpread is how far the blurred shadow reaches beyond its shape.
+func (d Decoration) shadowSpread() int {
+	return shadowBlurPasses * (d.ShadowRadius / 2)
+}
+
+// margins returns the space left of and above the content, and right of
+// and below it.
+func (d Decoration) margins() (topLeft, bottomRight image.Point) {
+	p := image.Pt(d.Padding, d.Padding)
+	if d.ShadowRadius <= 0 {
+		return p, p
+	}
+	s := d.shadowSpread()
+	topLeft = image.Pt(max(d.Padding, s), max(d.Padding, s-d.ShadowOffset))
+	bottomRight = image.Pt(max(d.Padding, s), max(d.Padding, s+d.ShadowOffset))
+	return topLeft, bottomRight
+}
+
+// Inset is where the content starts in the decorated image.
+func (d Decoration) Inset() image.Point {
+	topLeft, _ := d.margins()
+	return topLeft
+}
+
+// Size returns the size of the decorated image for content of size.
+func (d Decoration) Size(size image.Point) image.Point {
+	topLeft, bottomRight := d.margins()
+	return size.Add(topLeft).Add(bottomRight)
+}
+
+func (d Decoration) Apply(img image.Image) image.Image {
+	if d.IsZero() {
+		return img
+	}
+
+	b := img.Bounds()
+	format := formatNRGBA
+	if formatOf(img).deep() {
+		format = formatNRGBA64
+	}
+	dst := format.New(image.Rectangle{Max: d.Size(b.Size())})
+	content := image.Rectangle{d.Inset(), d.Inset().Add(b.Size())}
+
+	if dst.Bounds() != content {
+		d.drawBackground(dst)
+	}
+	if d.ShadowRadius > 0 {
+		shadow := roundRectMask(dst.Bounds(), content.Add(image.Pt(0, d.ShadowOffset)), d.CornerRadius)
+		for range shadowBlurPasses {
+			blurAlpha(shadow, d.ShadowRadius/2, true)
+			blurAlpha(shadow, d.ShadowRadius/2, false)
+		}
+		draw.DrawMask(dst, dst.Bounds(), image.NewUniform(d.ShadowColor), image.Point{}, shadow, image.Point{}, draw.Over)
+	}
+
+	if d.CornerRadius > 0 {
+		mask := roundRectMask(dst.Bounds(), content, d.CornerRadius)
+		draw.DrawMask(dst, content, img, b.Min, mask, content.Min, draw.Over)
+	} else {
+		draw.Draw(dst, content, img, b.Min, draw.Over)
+	}
+
+	if d.BorderWidth > 0 {
+		w, h := dst.Bounds().Dx(), dst.Bounds().Dy()
+		scanner := rasterx.NewScannerGV(w, h, dst, dst.Bounds())
+		stroker := rasterx.NewStroker(w, h, scanner)
+		stroker.SetStroke(fixed.I(d.BorderWidth), fixed.I(4), rasterx.ButtCap, nil, nil, rasterx.Miter)
+		hw := float64(d.BorderWidth) / 2
+		r := float64(max(d.CornerRadius-d.BorderWidth/2, 0))
+		rasterx.AddRoundRect(
+			float64(content.Min.X)+hw, float64(content.Min.Y)+hw,
+			float64(content.Max.X)-hw, float64(content.Max.Y)-hw,
+			r, r, 0, rasterx.RoundGap, stroker,
+		)
+		scanner.SetColor(d.BorderColor)
+		stroker.Draw()
+	}
+	return dst
+}
+
+const prefDecorationPresets = "decorationPresets"
+
+func LoadDecorationPresets(p fyne.Preferences) map[string]Decoration {
+	presets := map[string]Decoration{}
+	json.Unmarshal([]byte(p.String(prefDecorationPresets)), &presets)
+	return presets
+}
+
+func SaveDecorationPreset(p fyne.Preferences, name string, d Decoration) error {
+	presets := LoadDecorationPresets(p)
+	presets[name] = d
+	return storeDecorationPresets(p, presets)
+}
+
+func DeleteDecorationPreset(p fyne.Preferences, name string) error {
+	presets := LoadDecorationPresets(p)
+	delete(presets, name)
+	return storeDecorationPresets(p, presets)
+}
+
+func storeDecorationPresets(p fyne.Preferences, presets map[string]Decoration) error {
+	b, err := json.Marshal(presets)
+	if err != nil {
+		return err
+	}
+	p.SetString(prefDecorationPresets, string(b))
+	return nil
+}
+
+func (d Decoration) drawBackground(dst draw.Image) {
+	b := dst.Bounds()
+	if !d.Gradient {
+		draw.Draw(dst, b, image.NewUniform(d.Background), image.Point{}, draw.Src)
+		return
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		t := float64(y-b.Min.Y) / float64(max(b.Dy()-1, 1))
+		lerp := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5) }
+		c := color.NRGBA{
+			lerp(d.Background.R, d.Background2.R),
+			lerp(d.Background.G, d.Background2.G),
+			lerp(d.Background.B, d.Background2.B),
+			lerp(d.Background.A, d.Background2.A),
+		}
+		draw.Draw(dst, image.Rect(b.Min.X, y, b.Max.X, y+1), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
+
+func roundRectMask(bounds, r image.Rectangle, radius int) *image.Alpha {
+	mask := image.NewAlpha(bounds)
+	w, h := bounds.Dx(), bounds.Dy()
+	scanner := rasterx.NewScannerGV(w, h, mask, bounds)
+	filler := rasterx.NewFiller(w, h, scanner)
+	rad := float64(min(radius, r.Dx()/2, r.Dy()/2))
+	rasterx.AddRoundRect(
+		float64(r.Min.X), float64(r.Min.Y), float64(r.Max.X), float64(r.Max.Y),
+		rad, rad, 0, rasterx.RoundGap, filler,
+	)
+	scanner.SetColor(color.Opaque)
+	filler.Draw()
+	return mask
+}
+
+func blurAlpha(img *image.Alpha, radius int, horizontal bool) {
+	if radius <= 0 {
+		return
+	}
+	b := img.Bounds()
+	outer, inner := b.Dy(), b.Dx()
+	if !horizontal {
+		outer, inner = inner, outer
+	}
+	offset := func(o, i int) int {
+		if horizontal {
+			return img.PixOffset(b.Min.X+i, b.Min.Y+o)
+		}
+		return img.PixOffset(b.Min.X+o, b.Min.Y+i)
+	}
+
+	line := make([]uint8, inner)
+	n := radius*2 + 1
+	for o := 0; o < outer; o++ {
+		for i := range line {
+			line[i] = img.Pix[offset(o, i)]
+		}
+		sum := 0
+		for i := -radius; i <= radius; i++ {
+			if i >= 0 && i < inner {
+				sum += int(line[i])
+			}
+		}
+		for i := 0; i < inner; i++ {
+			img.Pix[offset(o, i)] = uint8(sum / n)
+			if j := i + radius + 1; j < inner {
+				sum += int(line[j])
+			}
+			if j := i - radius; j >= 0 {
+				sum -= int(line[j])
+			}
+		}
+	}
+}
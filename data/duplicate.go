@@ -0,0 +1,91 @@
+package data
+
+import (
+	"image"
+	"math/bits"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"golang.org/x/image/draw"
+)
+
+const hashSize = 16
+
+type PerceptualHash [hashSize * hashSize / 64]uint64
+
+func NewPerceptualHash(img image.Image) PerceptualHash {
+	gray := image.NewGray(image.Rect(0, 0, hashSize+1, hashSize))
+	draw.ApproxBiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var h PerceptualHash
+	for y := 0; y < hashSize; y++ {
+		for x := 0; x < hashSize; x++ {
+			if gray.GrayAt(x, y).Y < gray.GrayAt(x+1, y).Y {
+				bit := y*hashSize + x
+				h[bit/64] |= 1 << (bit % 64)
+			}
+		}
+	}
+	return h
+}
+
+func (h PerceptualHash) Similarity(o PerceptualHash) float64 {
+	diff := 0
+	for i := range h {
+		diff += bits.OnesCount64(h[i] ^ o[i])
+	}
+	return 1 - float64(diff)/float64(hashSize*hashSize)
+}
+
+// phashCache keeps an image's hash, which duplicate searches running in
+// the background read.
+type phashCache struct {
+	mu   sync.Mutex
+	hash *PerceptualHash
+}
+
+func (i *Image) PerceptualHash() PerceptualHash {
+	i.phash.mu.Lock()
+	defer i.phash.mu.Unlock()
+	if i.phash.hash == nil {
+		h := NewPerceptualHash(i.Source())
+		i.phash.hash = &h
+	}
+	return *i.phash.hash
+}
+
+func (l ImageList) Duplicates(threshold float64) []*Image {
+	list, _ := l.Get()
+	return FindDuplicates(list, threshold)
+}
+
+// FindDuplicates returns the images of list that are at least threshold
+// similar to an earlier one.
+func FindDuplicates(list []*Image, threshold float64) []*Image {
+	var dups []*Image
+	for i, a := range list {
+		if a.Generated != nil {
+			continue
+		}
+		for _, b := range list[:i] {
+			if b.Generated != nil || a.Bounds().Size() != b.Bounds().Size() {
+				continue
+			}
+			if a.PerceptualHash().Similarity(b.PerceptualHash()) >= threshold {
+				dups = append(dups, a)
+				break
+			}
+		}
+	}
+	return dups
+}
+
+func (l ImageList) ContainsURI(uri fyne.URI) bool {
+	list, _ := l.Get()
+	for _, v := range list {
+		if v.URI != nil && v.URI.String() == uri.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,297 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+var errNoExif = errors.New("no exif data")
+
+// maxMetadataSize bounds the EXIF and ICC data read from a file; larger
+// chunks are skipped.
+const maxMetadataSize = 16 << 20
+
+type exifInfo struct {
+	CaptureTime time.Time
+	Orientation int
+}
+
+// metadata is the EXIF and ICC profile data embedded in an image file.
+type metadata struct {
+	exif    []byte
+	profile []byte
+}
+
+const (
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+)
+
+func readExif(r io.Reader) (exifInfo, error) {
+	m, err := readMetadata(r)
+	if err != nil {
+		return exifInfo{}, err
+	}
+	if m.exif == nil {
+		return exifInfo{}, errNoExif
+	}
+	return parseExif(m.exif)
+}
+
+func readMetadata(r io.Reader) (metadata, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(8)
+	if err != nil {
+		return metadata{}, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0xff, 0xd8}):
+		return scanJPEG(br)
+	case bytes.Equal(magic, pngSignature):
+		return scanPNG(br)
+	default:
+		return metadata{}, nil
+	}
+}
+
+var iccJPEGPrefix = []byte("ICC_PROFILE\x00")
+
+func scanJPEG(r *bufio.Reader) (metadata, error) {
+	var m metadata
+	if _, err := r.Discard(2); err != nil {
+		return m, err
+	}
+	var iccChunks [][]byte
+	for {
+		var marker [2]byte
+		if _, err := io.ReadFull(r, marker[:]); err != nil {
+			return m, err
+		}
+		if marker[0] != 0xff {
+			break
+		}
+		switch marker[1] {
+		case 0xd8, 0x01, 0xd0, 0xd1, 0xd2, 0xd3, 0xd4, 0xd5, 0xd6, 0xd7:
+			continue
+		}
+		if marker[1] == 0xd9 || marker[1] == 0xda {
+			break
+		}
+
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return m, err
+		}
+		if length < 2 {
+			break
+		}
+		if marker[1] != 0xe1 && marker[1] != 0xe2 {
+			if _, err := r.Discard(int(length) - 2); err != nil {
+				return m, err
+			}
+			continue
+		}
+		segment, err := readSegment(r, int64(length)-2)
+		if err != nil {
+			return m, err
+		}
+		switch {
+		case marker[1] == 0xe1 && m.exif == nil && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			m.exif = segment[6:]
+		case marker[1] == 0xe2 && bytes.HasPrefix(segment, iccJPEGPrefix) && len(segment) > len(iccJPEGPrefix)+2:
+			seq := int(segment[len(iccJPEGPrefix)])
+			for len(iccChunks) < seq {
+				iccChunks = append(iccChunks, nil)
+			}
+			if seq > 0 {
+				iccChunks[seq-1] = segment[len(iccJPEGPrefix)+2:]
+			}
+		}
+	}
+	if len(iccChunks) > 0 && !slices.ContainsFunc(iccChunks, func(c []byte) bool { return c == nil }) {
+		m.profile = bytes.Join(iccChunks, nil)
+	}
+	return m, nil
+}
+
+func scanPNG(r *bufio.Reader) (metadata, error) {
+	var m metadata
+	if _, err := r.Discard(8); err != nil {
+		return m, err
+	}
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return m, err
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		switch typ := string(header[4:]); {
+		case (typ == "eXIf" || typ == "iCCP") && length <= maxMetadataSize:
+			chunk, err := readSegment(r, int64(length))
+			if err != nil {
+				return m, err
+			}
+			if string(header[4:]) == "eXIf" {
+				m.exif = chunk
+			} else {
+				m.profile = inflateICCP(chunk)
+			}
+			if _, err := r.Discard(4); err != nil {
+				return m, err
+			}
+			continue
+		case typ == "IDAT" || typ == "IEND":
+			return m, nil
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+			return m, err
+		}
+	}
+}
+
+func inflateICCP(chunk []byte) []byte {
+	i := bytes.IndexByte(chunk, 0)
+	if i < 0 || i+2 > len(chunk) || chunk[i+1] != 0 {
+		return nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(chunk[i+2:]))
+	if err != nil {
+		return nil
+	}
+	defer zr.Close()
+	profile, err := io.ReadAll(io.LimitReader(zr, maxMetadataSize+1))
+	if err != nil || len(profile) > maxMetadataSize {
+		return nil
+	}
+	return profile
+}
+
+// readSegment reads length bytes as they arrive instead of allocating the
+// length a file declares up front.
+func readSegment(r io.Reader, length int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, length); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type tiffEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+func parseExif(raw []byte) (exifInfo, error) {
+	if len(raw) < 8 {
+		return exifInfo{}, errNoExif
+	}
+	t := tiffReader{data: raw}
+	switch string(raw[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return exifInfo{}, errNoExif
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(raw[4:8]))
+	if err != nil {
+		return exifInfo{}, err
+	}
+	var x exifInfo
+	x.Orientation = int(t.uint32(ifd0[exifTagOrientation]))
+	if e, ok := ifd0[exifTagExifIFD]; ok {
+		if sub, err := t.readIFD(t.uint32(e)); err == nil {
+			x.CaptureTime = t.time(sub[exifTagDateTimeOriginal])
+		}
+	}
+	if x.CaptureTime.IsZero() {
+		x.CaptureTime = t.time(ifd0[exifTagDateTime])
+	}
+	return x, nil
+}
+
+func (t tiffReader) readIFD(offset uint32) (map[uint16]tiffEntry, error) {
+	if int(offset)+2 > len(t.data) {
+		return nil, errNoExif
+	}
+	n := int(t.order.Uint16(t.data[offset:]))
+	p := int(offset) + 2
+	if p+n*12 > len(t.data) {
+		return nil, errNoExif
+	}
+
+	entries := make(map[uint16]tiffEntry, n)
+	for i := 0; i < n; i++ {
+		b := t.data[p+i*12 : p+(i+1)*12]
+		e := tiffEntry{typ: t.order.Uint16(b[2:]), count: t.order.Uint32(b[4:])}
+		size := int(e.count) * tiffTypeSize(e.typ)
+		if size <= 4 {
+			e.value = b[8 : 8+size]
+		} else if off := int(t.order.Uint32(b[8:])); off+size <= len(t.data) {
+			e.value = t.data[off : off+size]
+		} else {
+			continue
+		}
+		entries[t.order.Uint16(b)] = e
+	}
+	return entries, nil
+}
+
+func tiffTypeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	default:
+		return 0
+	}
+}
+
+func (t tiffReader) uint32(e tiffEntry) uint32 {
+	switch {
+	case e.typ == 3 && len(e.value) >= 2:
+		return uint32(t.order.Uint16(e.value))
+	case e.typ == 4 && len(e.value) >= 4:
+		return t.order.Uint32(e.value)
+	default:
+		return 0
+	}
+}
+
+func (t tiffReader) time(e tiffEntry) time.Time {
+	if e.typ != 2 {
+		return time.Time{}
+	}
+	s := strings.TrimRight(string(e.value), "\x00 ")
+	v, err := time.ParseInLocation("2006:01:02 15:04:05", s, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return v
+}
@@ -0,0 +1,137 @@
+package data
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// tiffExif builds big-endian EXIF data with an orientation in IFD0 and a
+// capture time in the Exif sub-IFD.
+func tiffExif(orientation uint16, captured string) []byte {
+	var b bytes.Buffer
+	b.WriteString("MM\x00\x2a")
+	binary.Write(&b, binary.BigEndian, uint32(8))
+
+	// IFD0 at 8: two entries, then the sub-IFD at 38 and the string at 56.
+	binary.Write(&b, binary.BigEndian, uint16(2))
+	binary.Write(&b, binary.BigEndian, []uint16{exifTagOrientation, 3})
+	binary.Write(&b, binary.BigEndian, uint32(1))
+	binary.Write(&b, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&b, binary.BigEndian, []uint16{exifTagExifIFD, 4})
+	binary.Write(&b, binary.BigEndian, []uint32{1, 38})
+	binary.Write(&b, binary.BigEndian, uint32(0))
+
+	binary.Write(&b, binary.BigEndian, uint16(1))
+	binary.Write(&b, binary.BigEndian, []uint16{exifTagDateTimeOriginal, 2})
+	binary.Write(&b, binary.BigEndian, []uint32{uint32(len(captured) + 1), 56})
+	binary.Write(&b, binary.BigEndian, uint32(0))
+	b.WriteString(captured + "\x00")
+	return b.Bytes()
+}
+
+func pngWithChunks(t *testing.T, chunks ...pngChunk) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.Bytes()
+	// IHDR is always the first chunk: 8 bytes of header, 13 of data and 4
+	// of CRC after the signature.
+	ihdrEnd := len(pngSignature) + 25
+	var out bytes.Buffer
+	out.Write(src[:ihdrEnd])
+	for _, c := range chunks {
+		writePNGChunk(&out, c.typ, c.data)
+	}
+	out.Write(src[ihdrEnd:])
+	return out.Bytes()
+}
+
+func TestParseExif(t *testing.T) {
+	x, err := parseExif(tiffExif(6, "2024:05:06 07:08:09"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Orientation != 6 {
+		t.Errorf("Orientation = %d, want 6", x.Orientation)
+	}
+	if want := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local); !x.CaptureTime.Equal(want) {
+		t.Errorf("CaptureTime = %v, want %v", x.CaptureTime, want)
+	}
+}
+
+func TestParseExifInvalid(t *testing.T) {
+	for _, raw := range [][]byte{
+		nil,
+		[]byte("XX\x00\x2a\x00\x00\x00\x08"),
+		[]byte("MM\x00\x2a\xff\xff\xff\xff"),
+		append([]byte("MM\x00\x2a\x00\x00\x00\x08"), 0xff, 0xff),
+	} {
+		if _, err := parseExif(raw); err == nil {
+			t.Errorf("parseExif(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestReadExifPNG(t *testing.T) {
+	file := pngWithChunks(t, pngChunk{"eXIf", tiffExif(3, "2024:01:02 03:04:05")})
+	x, err := readExif(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Orientation != 3 {
+		t.Errorf("Orientation = %d, want 3", x.Orientation)
+	}
+}
+
+func TestReadExifJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2)), nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := append([]byte("Exif\x00\x00"), tiffExif(8, "2024:01:02 03:04:05")...)
+	var file bytes.Buffer
+	file.Write(buf.Bytes()[:2])
+	file.Write([]byte{0xff, 0xe1})
+	binary.Write(&file, binary.BigEndian, uint16(len(segment)+2))
+	file.Write(segment)
+	file.Write(buf.Bytes()[2:])
+
+	x, err := readExif(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Orientation != 8 {
+		t.Errorf("Orientation = %d, want 8", x.Orientation)
+	}
+}
+
+func TestScanPNGBogusLength(t *testing.T) {
+	var file bytes.Buffer
+	file.Write(pngSignature)
+	binary.Write(&file, binary.BigEndian, uint32(0xfffffff0))
+	file.WriteString("iCCP")
+	file.WriteString("truncated")
+
+	if _, err := readMetadata(&file); err == nil {
+		t.Error("truncated chunk read without error")
+	}
+}
+
+func TestScanPNGSkipsOversizedMetadata(t *testing.T) {
+	big := make([]byte, maxMetadataSize+1)
+	file := pngWithChunks(t, pngChunk{"eXIf", big}, pngChunk{"tEXt", []byte("k\x00v")})
+	m, err := readMetadata(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.exif != nil {
+		t.Error("oversized eXIf chunk was read")
+	}
+}
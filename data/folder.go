@@ -0,0 +1,185 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+)
+
+var SupportedExtensions = []string{".png", ".jpg", ".jpeg", ".webp", ".gif", ".apng", ".y4m"}
+
+func IsSupported(uri fyne.URI) bool {
+	return slices.Contains(SupportedExtensions, strings.ToLower(uri.Extension()))
+}
+
+func IsFolder(uri fyne.URI) bool {
+	ok, err := storage.CanList(uri)
+	return err == nil && ok
+}
+
+// ListImages returns the supported images in dir and its subfolders.
+// Folders reached again through a symbolic link are skipped.
+func ListImages(dir fyne.URI) ([]fyne.URI, error) {
+	return listImages(dir, map[string]bool{})
+}
+
+func listImages(dir fyne.URI, seen map[string]bool) ([]fyne.URI, error) {
+	key := folderKey(dir)
+	if seen[key] {
+		return nil, nil
+	}
+	seen[key] = true
+
+	children, err := storage.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var uris []fyne.URI
+	for _, v := range children {
+		if IsFolder(v) {
+			sub, err := listImages(v, seen)
+			if err != nil {
+				return nil, err
+			}
+			uris = append(uris, sub...)
+		} else if IsSupported(v) {
+			uris = append(uris, v)
+		}
+	}
+	return uris, nil
+}
+
+// folderKey identifies a folder by its resolved path, so every link to it
+// maps to the same key.
+func folderKey(dir fyne.URI) string {
+	if dir.Scheme() == "file" {
+		if path, err := filepath.EvalSymlinks(dir.Path()); err == nil {
+			return path
+		}
+	}
+	return dir.String()
+}
+
+type SortOrder int
+
+const (
+	SortByName SortOrder = iota
+	SortByModTime
+	SortByCaptureTime
+)
+
+var SortOrders = []SortOrder{SortByName, SortByModTime, SortByCaptureTime}
+
+func (o SortOrder) String() string {
+	switch o {
+	case SortByName:
+		return "File Name"
+	case SortByModTime:
+		return "Modification Time"
+	case SortByCaptureTime:
+		return "Capture Time"
+	default:
+		return ""
+	}
+}
+
+func SortURIs(uris []fyne.URI, order SortOrder) {
+	sortByURI(uris, func(uri fyne.URI) fyne.URI { return uri }, order)
+}
+
+func sortByURI[T any](items []T, uri func(T) fyne.URI, order SortOrder) {
+	if order == SortByName {
+		slices.SortStableFunc(items, func(a, b T) int {
+			return CompareNatural(uri(a).String(), uri(b).String())
+		})
+		return
+	}
+
+	keys := make(map[string]time.Time, len(items))
+	for _, v := range items {
+		u := uri(v)
+		switch order {
+		case SortByModTime:
+			keys[u.String()] = ModTime(u)
+		case SortByCaptureTime:
+			keys[u.String()] = CaptureTime(u)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b T) int {
+		ua, ub := uri(a), uri(b)
+		if c := keys[ua.String()].Compare(keys[ub.String()]); c != 0 {
+			return c
+		}
+		return CompareNatural(ua.String(), ub.String())
+	})
+}
+
+func ModTime(uri fyne.URI) time.Time {
+	if uri == nil || uri.Scheme() != "file" {
+		return time.Time{}
+	}
+	info, err := os.Stat(uri.Path())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func CaptureTime(uri fyne.URI) time.Time {
+	if uri == nil {
+		return time.Time{}
+	}
+	r, err := storage.Reader(uri)
+	if err != nil {
+		return ModTime(uri)
+	}
+	defer r.Close()
+	if x, err := readExif(r); err == nil && !x.CaptureTime.IsZero() {
+		return x.CaptureTime
+	}
+	return ModTime(uri)
+}
+
+func CompareNatural(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, nb := leadingDigits(a), leadingDigits(b)
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) - len(tb)
+			}
+			if c := strings.Compare(ta, tb); c != 0 {
+				return c
+			}
+			if len(na) != len(nb) {
+				return len(na) - len(nb)
+			}
+			a, b = a[len(na):], b[len(nb):]
+			continue
+		}
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if la, lb := unicode.ToLower(ra), unicode.ToLower(rb); la != lb {
+			return int(la) - int(lb)
+		}
+		a, b = a[sa:], b[sb:]
+	}
+	return len(a) - len(b)
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,35 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestListImagesSymlinkLoop(t *testing.T) {
+	test.NewApp()
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{filepath.Join(dir, "a.png"), filepath.Join(sub, "b.jpg"), filepath.Join(sub, "notes.txt")} {
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(dir, filepath.Join(sub, "loop")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+
+	uris, err := ListImages(storage.NewFileURI(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uris) != 2 {
+		t.Errorf("ListImages() = %v, want 2 images", uris)
+	}
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// pixelFormat is the in-memory layout used for merged and exported images,
+// chosen so that bit depth, alpha mode and grayscale sources survive the
+// pipeline through to the encoder.
+type pixelFormat int
+
+const (
+	formatRGBA pixelFormat = iota
+	formatNRGBA
+	formatRGBA64
+	formatNRGBA64
+	formatGray
+	formatGray16
+)
+
+func formatOf(img image.Image) pixelFormat {
+	switch img.(type) {
+	case *image.NRGBA:
+		return formatNRGBA
+	case *image.RGBA64:
+		return formatRGBA64
+	case *image.NRGBA64:
+		return formatNRGBA64
+	case *image.Gray:
+		return formatGray
+	case *image.Gray16:
+		return formatGray16
+	default:
+		return formatRGBA
+	}
+}
+
+func (f pixelFormat) deep() bool {
+	return f == formatRGBA64 || f == formatNRGBA64 || f == formatGray16
+}
+
+func (f pixelFormat) gray() bool {
+	return f == formatGray || f == formatGray16
+}
+
+func (f pixelFormat) straight() bool {
+	return f == formatNRGBA || f == formatNRGBA64
+}
+
+// mergeFormat picks the narrowest format that holds every source without
+// loss: grayscale only when all sources are, 16 bits per channel when any
+// source is, and straight alpha when any source uses it.
+func mergeFormat(images []image.Image) pixelFormat {
+	if len(images) == 0 {
+		return formatRGBA
+	}
+	gray, deep, straight := true, false, false
+	for _, v := range images {
+		f := formatOf(v)
+		gray = gray && f.gray()
+		deep = deep || f.deep()
+		straight = straight || f.straight()
+	}
+	switch {
+	case gray && deep:
+		return formatGray16
+	case gray:
+		return formatGray
+	case deep && straight:
+		return formatNRGBA64
+	case deep:
+		return formatRGBA64
+	case straight:
+		return formatNRGBA
+	default:
+		return formatRGBA
+	}
+}
+
+// withAlpha returns a format of the same depth that can hold color and
+// transparency.
+func (f pixelFormat) withAlpha() pixelFormat {
+	switch f {
+	case formatGray:
+		return formatNRGBA
+	case formatGray16:
+		return formatNRGBA64
+	default:
+		return f
+	}
+}
+
+func (f pixelFormat) New(r image.Rectangle) draw.Image {
+	switch f {
+	case formatNRGBA:
+		return image.NewNRGBA(r)
+	case formatRGBA64:
+		return image.NewRGBA64(r)
+	case formatNRGBA64:
+		return image.NewNRGBA64(r)
+	case formatGray:
+		return image.NewGray(r)
+	case formatGray16:
+		return image.NewGray16(r)
+	default:
+		return image.NewRGBA(r)
+	}
+}
+
+func subImage(img draw.Image, r image.Rectangle) draw.Image {
+	type subImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	if s, ok := img.(subImager); ok {
+		if sub, ok := s.SubImage(r).(draw.Image); ok {
+			return sub
+		}
+	}
+	return img
+}
@@ -0,0 +1,408 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"golang.org/x/image/draw"
+)
+
+var AnimationExtensions = []string{".gif", ".apng", ".y4m"}
+
+var ErrUnsupportedY4M = errors.New("unsupported Y4M colorspace")
+
+// FrameSampling selects which frames of an animation are kept. A frame is
+// kept when it is at least Interval frames after the last kept frame and
+// its content has scrolled at least MinScroll pixels since then. The first
+// frame is always kept.
+type FrameSampling struct {
+	Interval  int
+	MinScroll int
+}
+
+func IsAnimation(uri fyne.URI) bool {
+	ext := strings.ToLower(uri.Extension())
+	if slices.Contains(AnimationExtensions, ext) {
+		return true
+	}
+	if ext != ".png" {
+		return false
+	}
+	r, err := storage.Reader(uri)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+	return isAPNG(r)
+}
+
+func LoadFrames(uri fyne.URI, sampling FrameSampling, space ColorSpace) ([]*Image, error) {
+	r, err := storage.Reader(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var images []*Image
+	var lastRows []uint64
+	index, last := 0, 0
+	err = decodeFrames(bufio.NewReader(r), strings.ToLower(uri.Extension()), func(frame image.Image) {
+		index++
+		if len(images) > 0 && index-last < max(sampling.Interval, 1) {
+			return
+		}
+		rows := rowHashes(frame)
+		if len(images) > 0 && sampling.MinScroll > 0 && scrollDistance(lastRows, rows) < sampling.MinScroll {
+			return
+		}
+		b := frame.Bounds()
+		dst := formatOf(frame).New(b)
+		draw.Draw(dst, b, frame, b.Min, draw.Src)
+		img := NewImage(uri, convertColor(dst, srgbProfile, space))
+		img.Frame = index
+		images = append(images, img)
+		last, lastRows = index, rows
+	})
+	return images, err
+}
+
+func decodeFrames(r *bufio.Reader, ext string, yield func(image.Image)) error {
+	switch ext {
+	case ".gif":
+		return decodeGIF(r, yield)
+	case ".y4m":
+		return decodeY4M(r, yield)
+	default:
+		return decodeAPNG(r, yield)
+	}
+}
+
+// scrollDistance estimates how far content moved vertically between two
+// frames from the offset most matching rows agree on. Frames without
+// enough matching rows are treated as having scrolled completely.
+func scrollDistance(prev, rows []uint64) int {
+	index := indexRows(prev)
+	votes := make(map[int]int)
+	for y, h := range rows {
+		if h == 0 {
+			continue
+		}
+		ys := index[h]
+		if len(ys) > maxRowRepeats {
+			continue
+		}
+		for _, v := range ys {
+			votes[v-y]++
+		}
+	}
+	best, score := len(rows), 0
+	for d, v := range votes {
+		if v > score || v == score && abs(d) < abs(best) {
+			best, score = d, v
+		}
+	}
+	return abs(best)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func decodeGIF(r io.Reader, yield func(image.Image)) error {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return err
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	for i, frame := range g.Image {
+		var prev *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			prev = image.NewRGBA(canvas.Bounds())
+			copy(prev.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		yield(canvas)
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, prev.Pix)
+		}
+	}
+	return nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// maxPNGChunk is the largest chunk length the PNG specification allows.
+const maxPNGChunk = 1<<31 - 1
+
+// readPNGChunk copies the chunk data as it arrives instead of allocating
+// the declared length up front, so a bogus length cannot force a huge
+// allocation.
+func readPNGChunk(r io.Reader) (pngChunk, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return pngChunk{}, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length > maxPNGChunk {
+		return pngChunk{}, png.FormatError("invalid chunk length")
+	}
+	var data bytes.Buffer
+	if _, err := io.CopyN(&data, r, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return pngChunk{}, err
+	}
+	var crc [4]byte
+	if _, err := io.ReadFull(r, crc[:]); err != nil {
+		return pngChunk{}, err
+	}
+	return pngChunk{string(header[4:]), data.Bytes()}, nil
+}
+
+func writePNGChunk(w *bytes.Buffer, typ string, data []byte) {
+	binary.Write(w, binary.BigEndian, uint32(len(data)))
+	w.WriteString(typ)
+	w.Write(data)
+	binary.Write(w, binary.BigEndian, crc32.ChecksumIEEE(append([]byte(typ), data...)))
+}
+
+func isAPNG(r io.Reader) bool {
+	var sig [8]byte
+	if _, err := io.ReadFull(r, sig[:]); err != nil || !bytes.Equal(sig[:], pngSignature) {
+		return false
+	}
+	for {
+		c, err := readPNGChunk(r)
+		if err != nil || c.typ == "IDAT" {
+			return false
+		}
+		if c.typ == "acTL" {
+			return true
+		}
+	}
+}
+
+type apngFrame struct {
+	rect           image.Rectangle
+	dispose, blend byte
+	data           [][]byte
+}
+
+func decodeAPNG(r io.Reader, yield func(image.Image)) error {
+	var sig [8]byte
+	if _, err := io.ReadFull(r, sig[:]); err != nil {
+		return err
+	}
+	if !bytes.Equal(sig[:], pngSignature) {
+		return png.FormatError("not a PNG file")
+	}
+
+	var ihdr []byte
+	var shared []pngChunk
+	var frames []*apngFrame
+	var current *apngFrame
+	seenIDAT := false
+	for {
+		c, err := readPNGChunk(r)
+		if err != nil {
+			return err
+		}
+		switch c.typ {
+		case "IHDR":
+			ihdr = c.data
+		case "acTL":
+		case "fcTL":
+			if len(c.data) < 26 {
+				return png.FormatError("invalid fcTL chunk")
+			}
+			x, y := binary.BigEndian.Uint32(c.data[12:]), binary.BigEndian.Uint32(c.data[16:])
+			w, h := binary.BigEndian.Uint32(c.data[4:]), binary.BigEndian.Uint32(c.data[8:])
+			current = &apngFrame{
+				rect:    image.Rect(int(x), int(y), int(x+w), int(y+h)),
+				dispose: c.data[24],
+				blend:   c.data[25],
+			}
+			frames = append(frames, current)
+		case "IDAT":
+			seenIDAT = true
+			if current != nil {
+				current.data = append(current.data, c.data)
+			}
+		case "fdAT":
+			if current != nil && len(c.data) > 4 {
+				current.data = append(current.data, c.data[4:])
+			}
+		case "IEND":
+			return composeAPNG(ihdr, shared, frames, yield)
+		default:
+			if !seenIDAT {
+				shared = append(shared, c)
+			}
+		}
+	}
+}
+
+func composeAPNG(ihdr []byte, shared []pngChunk, frames []*apngFrame, yield func(image.Image)) error {
+	if len(ihdr) < 13 {
+		return png.FormatError("missing IHDR chunk")
+	}
+	w, h := binary.BigEndian.Uint32(ihdr), binary.BigEndian.Uint32(ihdr[4:])
+	canvas := image.NewRGBA(image.Rect(0, 0, int(w), int(h)))
+
+	for _, f := range frames {
+		if len(f.data) == 0 {
+			continue
+		}
+		var buf bytes.Buffer
+		buf.Write(pngSignature)
+		header := bytes.Clone(ihdr)
+		binary.BigEndian.PutUint32(header, uint32(f.rect.Dx()))
+		binary.BigEndian.PutUint32(header[4:], uint32(f.rect.Dy()))
+		writePNGChunk(&buf, "IHDR", header)
+		for _, c := range shared {
+			writePNGChunk(&buf, c.typ, c.data)
+		}
+		writePNGChunk(&buf, "IDAT", bytes.Join(f.data, nil))
+		writePNGChunk(&buf, "IEND", nil)
+		img, err := png.Decode(&buf)
+		if err != nil {
+			return err
+		}
+
+		var prev *image.RGBA
+		if f.dispose == 2 {
+			prev = image.NewRGBA(canvas.Bounds())
+			copy(prev.Pix, canvas.Pix)
+		}
+		op := draw.Over
+		if f.blend == 0 {
+			op = draw.Src
+		}
+		draw.Draw(canvas, f.rect, img, image.Point{}, op)
+		yield(canvas)
+		switch f.dispose {
+		case 1:
+			draw.Draw(canvas, f.rect, image.Transparent, image.Point{}, draw.Src)
+		case 2:
+			copy(canvas.Pix, prev.Pix)
+		}
+	}
+	return nil
+}
+
+func decodeY4M(r *bufio.Reader, yield func(image.Image)) error {
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(header)
+	if len(fields) == 0 || fields[0] != "YUV4MPEG2" {
+		return errors.New("not a Y4M file")
+	}
+	w, h, colorspace, full := 0, 0, "420", false
+	for _, f := range fields[1:] {
+		switch f[0] {
+		case 'W':
+			w, _ = strconv.Atoi(f[1:])
+		case 'H':
+			h, _ = strconv.Atoi(f[1:])
+		case 'C':
+			colorspace = f[1:]
+		case 'X':
+			full = strings.EqualFold(f[1:], "COLORRANGE=FULL")
+		}
+	}
+	if w <= 0 || h <= 0 {
+		return errors.New("invalid Y4M dimensions")
+	}
+
+	rect := image.Rect(0, 0, w, h)
+	var frame image.Image
+	var planes [][]byte
+	switch {
+	case colorspace == "mono":
+		gray := image.NewGray(rect)
+		frame, planes = gray, [][]byte{gray.Pix}
+	case colorspace == "420" || colorspace == "420jpeg" || colorspace == "420mpeg2" || colorspace == "420paldv":
+		ycc := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+		frame, planes = ycc, [][]byte{ycc.Y, ycc.Cb, ycc.Cr}
+	case colorspace == "422":
+		ycc := image.NewYCbCr(rect, image.YCbCrSubsampleRatio422)
+		frame, planes = ycc, [][]byte{ycc.Y, ycc.Cb, ycc.Cr}
+	case colorspace == "444":
+		ycc := image.NewYCbCr(rect, image.YCbCrSubsampleRatio444)
+		frame, planes = ycc, [][]byte{ycc.Y, ycc.Cb, ycc.Cr}
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedY4M, colorspace)
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(line, "FRAME") {
+			return errors.New("invalid Y4M frame header")
+		}
+		for i, p := range planes {
+			if _, err := io.ReadFull(r, p); err != nil {
+				return err
+			}
+			if !full {
+				expandRange(p, i > 0)
+			}
+		}
+		yield(frame)
+	}
+}
+
+// expandRange maps limited-range BT.601 samples, 16–235 for luma and
+// 16–240 for chroma, to the full range image.YCbCr expects.
+func expandRange(plane []byte, chroma bool) {
+	var table [256]byte
+	for v := range table {
+		var f float64
+		if chroma {
+			f = (float64(v)-128)*255/224 + 128
+		} else {
+			f = (float64(v) - 16) * 255 / 219
+		}
+		table[v] = uint8(min(max(math.Round(f), 0), 255))
+	}
+	for i, v := range plane {
+		plane[i] = table[v]
+	}
+}
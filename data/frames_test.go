@@ -0,0 +1,177 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func y4mFile(header string, frames int, y, c byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(header + "\n")
+	for range frames {
+		b.WriteString("FRAME\n")
+		b.Write(bytes.Repeat([]byte{y}, 4*2))
+		b.Write(bytes.Repeat([]byte{c}, 2*1*2))
+	}
+	return b.Bytes()
+}
+
+func decodeY4MFrames(t *testing.T, file []byte) ([]color.Color, error) {
+	t.Helper()
+	var colors []color.Color
+	err := decodeY4M(bufio.NewReader(bytes.NewReader(file)), func(img image.Image) {
+		colors = append(colors, img.At(0, 0))
+	})
+	return colors, err
+}
+
+func TestDecodeY4M(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		y       byte
+		want    uint8
+		wantErr error
+	}{
+		{"default chroma", "YUV4MPEG2 W4 H2 F30:1", 235, 0xff, nil},
+		{"mpeg2 siting", "YUV4MPEG2 W4 H2 C420mpeg2", 235, 0xff, nil},
+		{"paldv siting", "YUV4MPEG2 W4 H2 C420paldv", 16, 0x00, nil},
+		{"jpeg siting", "YUV4MPEG2 W4 H2 C420jpeg", 16, 0x00, nil},
+		{"full range", "YUV4MPEG2 W4 H2 C420jpeg XCOLORRANGE=FULL", 16, 0x10, nil},
+		{"high bit depth", "YUV4MPEG2 W4 H2 C420p10", 0, 0, ErrUnsupportedY4M},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			colors, err := decodeY4MFrames(t, y4mFile(tt.header, 2, tt.y, 128))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if len(colors) != 2 {
+				t.Fatalf("decoded %d frames, want 2", len(colors))
+			}
+			r, g, b, _ := colors[0].RGBA()
+			if uint8(r>>8) != tt.want || uint8(g>>8) != tt.want || uint8(b>>8) != tt.want {
+				t.Errorf("pixel = %v, want gray %d", colors[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeY4MTruncated(t *testing.T) {
+	file := y4mFile("YUV4MPEG2 W4 H2", 1, 128, 128)
+	if _, err := decodeY4MFrames(t, file[:len(file)-3]); err == nil {
+		t.Error("truncated frame decoded without error")
+	}
+}
+
+func TestReadPNGChunkBogusLength(t *testing.T) {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(0x7fffffff))
+	b.WriteString("IDAT")
+	b.WriteString("short")
+	if _, err := readPNGChunk(&b); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	b.Reset()
+	binary.Write(&b, binary.BigEndian, uint32(0xffffffff))
+	b.WriteString("IDAT")
+	if _, err := readPNGChunk(&b); err == nil {
+		t.Error("chunk longer than the PNG limit was accepted")
+	}
+}
+
+// encodeAPNG builds an animated PNG whose frames are the given images,
+// all of the same size.
+func encodeAPNG(t *testing.T, frames []image.Image) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for i, img := range frames {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		r := bytes.NewReader(buf.Bytes()[len(pngSignature):])
+		for {
+			c, err := readPNGChunk(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.typ == "IEND" {
+				break
+			}
+			switch {
+			case c.typ == "IHDR" && i == 0:
+				writePNGChunk(&out, "IHDR", c.data)
+				actl := binary.BigEndian.AppendUint32(nil, uint32(len(frames)))
+				writePNGChunk(&out, "acTL", binary.BigEndian.AppendUint32(actl, 0))
+			case c.typ == "IDAT":
+				b := img.Bounds()
+				fctl := binary.BigEndian.AppendUint32(nil, uint32(i*2))
+				for _, v := range []int{b.Dx(), b.Dy(), 0, 0} {
+					fctl = binary.BigEndian.AppendUint32(fctl, uint32(v))
+				}
+				fctl = append(fctl, 0, 1, 0, 1, 0, 0)
+				writePNGChunk(&out, "fcTL", fctl)
+				if i == 0 {
+					writePNGChunk(&out, "IDAT", c.data)
+				} else {
+					writePNGChunk(&out, "fdAT", append(binary.BigEndian.AppendUint32(nil, uint32(i*2+1)), c.data...))
+				}
+			}
+		}
+	}
+	writePNGChunk(&out, "IEND", nil)
+	return out.Bytes()
+}
+
+func TestDecodeAPNG(t *testing.T) {
+	colors := []color.NRGBA{{0xff, 0, 0, 0xff}, {0, 0xff, 0, 0xff}, {0, 0, 0xff, 0xff}}
+	var frames []image.Image
+	for _, c := range colors {
+		img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		frames = append(frames, img)
+	}
+	file := encodeAPNG(t, frames)
+
+	if !isAPNG(bytes.NewReader(file)) {
+		t.Fatal("isAPNG() = false")
+	}
+	var got []color.Color
+	if err := decodeAPNG(bytes.NewReader(file), func(img image.Image) {
+		got = append(got, img.At(1, 1))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(colors) {
+		t.Fatalf("decoded %d frames, want %d", len(got), len(colors))
+	}
+	for i, c := range got {
+		if color.NRGBAModel.Convert(c) != colors[i] {
+			t.Errorf("frame %d = %v, want %v", i, c, colors[i])
+		}
+	}
+}
+
+func TestIsAPNGStill(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 2)))
+	if isAPNG(strings.NewReader(buf.String())) {
+		t.Error("isAPNG() = true for a still PNG")
+	}
+}
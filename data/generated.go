@@ -0,0 +1,250 @@
+package data
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+type GeneratedKind int
+
+const (
+	GeneratedBanner GeneratedKind = iota
+	GeneratedSpacer
+	GeneratedStep
+)
+
+var GeneratedKinds = []GeneratedKind{GeneratedBanner, GeneratedSpacer, GeneratedStep}
+
+func (k GeneratedKind) String() string {
+	switch k {
+	case GeneratedBanner:
+		return "Banner"
+	case GeneratedSpacer:
+		return "Spacer"
+	case GeneratedStep:
+		return "Step Badge"
+	default:
+		return ""
+	}
+}
+
+// Generated describes a list item that is drawn instead of loaded from a
+// file: a text banner, a solid spacer, or a numbered step badge.
+type Generated struct {
+	Kind       GeneratedKind
+	Width      int
+	Height     int
+	Heading    string
+	Body       string
+	Step       int
+	Color      color.NRGBA
+	Background color.NRGBA
+}
+
+const (
+	defaultGeneratedWidth = 800
+	generatedPadding      = 24
+	bannerHeadingSize     = 32
+	bannerBodySize        = 18
+	bannerLineGap         = 8
+	stepBadgeSize         = 48
+	stepTextSize          = 24
+)
+
+func NewGenerated(kind GeneratedKind, width int) Generated {
+	if width <= 0 {
+		width = defaultGeneratedWidth
+	}
+	g := Generated{
+		Kind:       kind,
+		Width:      width,
+		Color:      color.NRGBA{0x20, 0x20, 0x20, 0xff},
+		Background: color.NRGBA{0xff, 0xff, 0xff, 0xff},
+	}
+	switch kind {
+	case GeneratedBanner:
+		g.Heading = "Heading"
+	case GeneratedSpacer:
+		g.Height = 48
+		g.Color = g.Background
+	case GeneratedStep:
+		g.Step = 1
+		g.Color = color.NRGBA{0x1e, 0x88, 0xe5, 0xff}
+	}
+	return g
+}
+
+func NewGeneratedImage(g Generated) *Image {
+	i := NewImage(nil, g.Render())
+	i.Generated = &g
+	return i
+}
+
+func (i *Image) Name() string {
+	if i.Generated != nil {
+		if i.Generated.Kind == GeneratedStep {
+			return fmt.Sprintf("Step %d", i.Generated.Step)
+		}
+		return i.Generated.Kind.String()
+	}
+	if i.URI == nil {
+		return ""
+	}
+	if i.Frame > 0 {
+		return fmt.Sprintf("%s #%d", i.URI.Name(), i.Frame)
+	}
+	return i.URI.Name()
+}
+
+func (g Generated) Render() image.Image {
+	w := max(g.Width, 1)
+	switch g.Kind {
+	case GeneratedBanner:
+		return g.renderBanner(w)
+	case GeneratedStep:
+		return g.renderStep(w)
+	default:
+		dst := image.NewRGBA(image.Rect(0, 0, w, max(g.Height, 1)))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(g.Color), image.Point{}, draw.Src)
+		return dst
+	}
+}
+
+func (g Generated) renderBanner(w int) image.Image {
+	textWidth := max(w-generatedPadding*2, 1)
+	heading := wrapText(g.Heading, bannerHeadingSize, textWidth)
+	body := wrapText(g.Body, bannerBodySize, textWidth)
+
+	h := generatedPadding * 2
+	_, hh := measureText(heading, bannerHeadingSize)
+	_, bh := measureText(body, bannerBodySize)
+	if g.Heading != "" {
+		h += hh
+	}
+	if g.Body != "" {
+		h += bh
+		if g.Heading != "" {
+			h += bannerLineGap
+		}
+	}
+	h = max(h, g.Height)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(g.Background), image.Point{}, draw.Src)
+	y := generatedPadding
+	if g.Heading != "" {
+		drawText(dst, heading, bannerHeadingSize, g.Color, image.Pt(generatedPadding, y))
+		y += hh + bannerLineGap
+	}
+	if g.Body != "" {
+		drawText(dst, body, bannerBodySize, g.Color, image.Pt(generatedPadding, y))
+	}
+	return dst
+}
+
+func (g Generated) renderStep(w int) image.Image {
+	h := max(g.Height, stepBadgeSize+generatedPadding)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(g.Background), image.Point{}, draw.Src)
+
+	r := float64(stepBadgeSize) / 2
+	cx, cy := float64(generatedPadding)+r, float64(h)/2
+	scanner := rasterx.NewScannerGV(w, h, dst, dst.Bounds())
+	filler := rasterx.NewFiller(w, h, scanner)
+	rasterx.AddCircle(cx, cy, r, filler)
+	scanner.SetColor(g.Color)
+	filler.Draw()
+
+	label := fmt.Sprint(g.Step)
+	tw, th := measureText(label, stepTextSize)
+	drawText(dst, label, stepTextSize, color.NRGBA{0xff, 0xff, 0xff, 0xff},
+		image.Pt(int(math.Round(cx))-tw/2, int(math.Round(cy))-th/2))
+	if g.Heading != "" {
+		_, hh := measureText(g.Heading, bannerBodySize)
+		drawText(dst, g.Heading, bannerBodySize, color.NRGBA{0x20, 0x20, 0x20, 0xff},
+			image.Pt(generatedPadding*2+stepBadgeSize, h/2-hh/2))
+	}
+	return dst
+}
+
+func drawText(dst draw.Image, text string, size float64, clr color.Color, at image.Point) {
+	face := textFace(size)
+	defer face.Close()
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(clr), Face: face}
+	for i, line := range splitLines(text) {
+		d.Dot = fixed.Point26_6{
+			X: fixed.I(at.X),
+			Y: fixed.I(at.Y) + face.Metrics().Ascent + face.Metrics().Height*fixed.Int26_6(i),
+		}
+		d.DrawString(line)
+	}
+}
+
+func wrapText(text string, size float64, width int) string {
+	face := textFace(size)
+	defer face.Close()
+
+	var lines []string
+	for _, para := range splitLines(text) {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			next := word
+			if line != "" {
+				next = line + " " + word
+			}
+			if line != "" && font.MeasureString(face, next).Ceil() > width {
+				lines = append(lines, line)
+				next = word
+			}
+			line = next
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (l ImageList) Width() int {
+	list, _ := l.Get()
+	w := 0
+	for _, v := range list {
+		c, _ := v.Crop.Get()
+		w = max(w, c.Dx())
+	}
+	return w
+}
+
+func (l ImageList) NextStep() int {
+	list, _ := l.Get()
+	n := 0
+	for _, v := range list {
+		if v.Generated != nil && v.Generated.Kind == GeneratedStep {
+			n = max(n, v.Generated.Step)
+		}
+	}
+	return n + 1
+}
+
+func (l ImageList) Insert(index int, img *Image) error {
+	list, _ := l.Get()
+	index = min(max(index, 0), len(list))
+	return l.Commit(append(list[:index], append([]*Image{img}, list[index:]...)...))
+}
+
+func (l ImageList) Replace(old, img *Image) error {
+	list, _ := l.Get()
+	i := slices.Index(list, old)
+	if i < 0 {
+		return nil
+	}
+	list[i] = img
+	return l.Commit(list)
+}
@@ -0,0 +1,162 @@
+package data
+
+import (
+	"encoding/json"
+	"image"
+	"math"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+)
+
+const layoutMapSuffix = ".layout.json"
+
+// LayoutMap records where each item of the list landed in the saved image.
+// All rectangles are in output pixels, except Crop which is in the item's
+// own pixels after its transform. Seams are the bands between consecutive
+// items, with zero height when they touch; a mosaic has none.
+type LayoutMap struct {
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Images []LayoutEntry `json:"images"`
+	Seams  []LayoutRect  `json:"seams"`
+}
+
+type LayoutEntry struct {
+	URI          string     `json:"uri,omitempty"`
+	Name         string     `json:"name"`
+	Frame        int        `json:"frame,omitempty"`
+	Generated    string     `json:"generated,omitempty"`
+	Crop         LayoutRect `json:"crop"`
+	TrimLeading  int        `json:"trimLeading"`
+	TrimTrailing int        `json:"trimTrailing"`
+	Rect         LayoutRect `json:"rect"`
+
+	// Parts lists every piece of the item when columns split it.
+	Parts []LayoutRect `json:"parts,omitempty"`
+}
+
+type LayoutRect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func newLayoutRect(r image.Rectangle) LayoutRect {
+	return LayoutRect{r.Min.X, r.Min.Y, r.Dx(), r.Dy()}
+}
+
+func (l ImageList) LayoutMap() LayoutMap {
+	return l.layoutMap(l.sizeColumns())
+}
+
+// layoutMap takes the column layout the image was rendered with, so saving
+// does not compose the list again.
+func (l ImageList) layoutMap(columns *ColumnLayout) LayoutMap {
+	list, _ := l.Get()
+	rects := l.Layout()
+	decoration, _ := l.Decoration.Get()
+	output, _ := l.Output.Get()
+	mosaic, _ := l.Mosaic.Get()
+
+	src := l.stampedSize(columns)
+	dst := output.Size(src)
+	sx, sy := 1.0, 1.0
+	if src.X > 0 && src.Y > 0 {
+		sx, sy = float64(dst.X)/float64(src.X), float64(dst.Y)/float64(src.Y)
+	}
+	var inset image.Point
+	if !decoration.IsZero() {
+		inset = decoration.Inset()
+	}
+	toSheet := func(r image.Rectangle) []image.Rectangle { return []image.Rectangle{r} }
+	if columns != nil {
+		toSheet = columns.SheetRects
+	}
+	toOutput := func(r image.Rectangle) []LayoutRect {
+		var rects []LayoutRect
+		for _, v := range toSheet(r) {
+			v = v.Add(inset)
+			rects = append(rects, newLayoutRect(image.Rect(
+				int(math.Round(float64(v.Min.X)*sx)), int(math.Round(float64(v.Min.Y)*sy)),
+				int(math.Round(float64(v.Max.X)*sx)), int(math.Round(float64(v.Max.Y)*sy)),
+			)))
+		}
+		return rects
+	}
+
+	m := LayoutMap{Width: dst.X, Height: dst.Y, Images: make([]LayoutEntry, len(list)), Seams: []LayoutRect{}}
+	for i, v := range list {
+		crop, _ := v.Crop.Get()
+		leading, _ := v.TrimLeading.Get()
+		trailing, _ := v.TrimTrailing.Get()
+		e := LayoutEntry{
+			Name:         v.Name(),
+			Frame:        v.Frame,
+			Crop:         newLayoutRect(crop),
+			TrimLeading:  leading,
+			TrimTrailing: trailing,
+		}
+		if parts := toOutput(rects[i]); len(parts) > 0 {
+			e.Rect = parts[0]
+			if len(parts) > 1 {
+				e.Parts = parts
+			}
+		}
+		if v.URI != nil {
+			e.URI = v.URI.String()
+		}
+		if v.Generated != nil {
+			e.Generated = v.Generated.Kind.String()
+		}
+		m.Images[i] = e
+		if i > 0 && !mosaic.active() {
+			seam := image.Rect(0, rects[i-1].Max.Y, max(rects[i-1].Dx(), rects[i].Dx()), rects[i].Min.Y)
+			if seam.Dy() == 0 {
+				seam.Max.Y++
+				if parts := toOutput(seam); len(parts) > 0 {
+					parts[0].Height = 0
+					m.Seams = append(m.Seams, parts[0])
+				}
+			} else {
+				m.Seams = append(m.Seams, toOutput(seam)...)
+			}
+		}
+	}
+	return m
+}
+
+// ImageAt returns the topmost item covering p in merged coordinates, or
+// nil.
+func (l ImageList) ImageAt(p image.Point) *Image {
+	list, _ := l.Get()
+	rects := l.Layout()
+	for i := len(rects) - 1; i >= 0; i-- {
+		if p.In(rects[i]) {
+			return list[i]
+		}
+	}
+	return nil
+}
+
+func (l ImageList) writeLayoutMap(uri fyne.URI, columns *ColumnLayout) error {
+	parent, err := storage.Parent(uri)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(uri.Name(), uri.Extension())
+	sidecar, err := storage.Child(parent, name+layoutMapSuffix)
+	if err != nil {
+		return err
+	}
+	w, err := storage.Writer(sidecar)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l.layoutMap(columns))
+}
@@ -0,0 +1,181 @@
+package data
+
+import (
+	"image"
+	"slices"
+	"sync"
+
+	"fyne.io/fyne/v2"
+)
+
+// history holds list snapshots for undo and redo. Every change to the
+// order or membership of the list goes through Commit, so that undoing one
+// change never drops another.
+type history struct {
+	mu         sync.Mutex
+	undo, redo [][]*Image
+}
+
+func (l ImageList) IndexOf(image *Image) int {
+	list, _ := l.Get()
+	return slices.Index(list, image)
+}
+
+func (l ImageList) MergedOrigin(index int) image.Point {
+	rects := l.Layout()
+	if index < len(rects) {
+		return rects[index].Min
+	}
+	if len(rects) == 0 {
+		return image.Point{}
+	}
+	return image.Pt(0, rects[len(rects)-1].Max.Y)
+}
+
+func (l ImageList) Subset(images []*Image) ImageList {
+	list, _ := l.Get()
+	sub := NewImageList()
+	stamp, _ := l.Stamp.Get()
+	sub.Stamp.Set(stamp)
+	decoration, _ := l.Decoration.Get()
+	sub.Decoration.Set(decoration)
+	separator, _ := l.Separator.Get()
+	sub.Separator.Set(separator)
+	columns, _ := l.Columns.Get()
+	sub.Columns.Set(columns)
+	mosaic, _ := l.Mosaic.Get()
+	sub.Mosaic.Set(mosaic)
+	output, _ := l.Output.Get()
+	sub.Output.Set(output)
+	space, _ := l.ColorSpace.Get()
+	sub.ColorSpace.Set(space)
+	sub.Set(slices.DeleteFunc(list, func(v *Image) bool { return !slices.Contains(images, v) }))
+	return sub
+}
+
+func (l ImageList) Commit(list []*Image) error {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	prev, _ := l.Get()
+	l.history.undo = append(l.history.undo, prev)
+	l.history.redo = nil
+	return l.Set(list)
+}
+
+func (l ImageList) CanUndo() bool {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	return len(l.history.undo) > 0
+}
+
+func (l ImageList) CanRedo() bool {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	return len(l.history.redo) > 0
+}
+
+func (l ImageList) Undo() error {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	if len(l.history.undo) == 0 {
+		return nil
+	}
+	cur, _ := l.Get()
+	prev := l.history.undo[len(l.history.undo)-1]
+	l.history.undo = l.history.undo[:len(l.history.undo)-1]
+	l.history.redo = append(l.history.redo, cur)
+	return l.Set(prev)
+}
+
+func (l ImageList) Redo() error {
+	l.history.mu.Lock()
+	defer l.history.mu.Unlock()
+	if len(l.history.redo) == 0 {
+		return nil
+	}
+	cur, _ := l.Get()
+	next := l.history.redo[len(l.history.redo)-1]
+	l.history.redo = l.history.redo[:len(l.history.redo)-1]
+	l.history.undo = append(l.history.undo, cur)
+	return l.Set(next)
+}
+
+// Add appends images to the end of the list as one undoable change.
+func (l ImageList) Add(images ...*Image) error {
+	list, _ := l.Get()
+	return l.Commit(append(list, images...))
+}
+
+func (l ImageList) Clear() error {
+	return l.Commit([]*Image{})
+}
+
+func (l ImageList) Reverse() error {
+	list, _ := l.Get()
+	slices.Reverse(list)
+	return l.Commit(list)
+}
+
+func (l ImageList) Swap(i, j int) error {
+	list, _ := l.Get()
+	list[i], list[j] = list[j], list[i]
+	return l.Commit(list)
+}
+
+func (l ImageList) RemoveAll(images []*Image) error {
+	list, _ := l.Get()
+	return l.Commit(slices.DeleteFunc(list, func(v *Image) bool { return slices.Contains(images, v) }))
+}
+
+func (l ImageList) MoveToTop(images []*Image) error {
+	moved, rest := l.partition(images)
+	return l.Commit(append(moved, rest...))
+}
+
+func (l ImageList) MoveToBottom(images []*Image) error {
+	moved, rest := l.partition(images)
+	return l.Commit(append(rest, moved...))
+}
+
+func (l ImageList) ReverseSubset(images []*Image) error {
+	list, _ := l.Get()
+	var indexes []int
+	for i, v := range list {
+		if slices.Contains(images, v) {
+			indexes = append(indexes, i)
+		}
+	}
+	for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+		list[indexes[i]], list[indexes[j]] = list[indexes[j]], list[indexes[i]]
+	}
+	return l.Commit(list)
+}
+
+func (l ImageList) Sort(order SortOrder) error {
+	list, _ := l.Get()
+	var indexes []int
+	var files []*Image
+	for i, v := range list {
+		if v.URI != nil {
+			indexes = append(indexes, i)
+			files = append(files, v)
+		}
+	}
+	sortByURI(files, func(v *Image) fyne.URI { return v.URI }, order)
+	for i, v := range files {
+		list[indexes[i]] = v
+	}
+	return l.Commit(list)
+}
+
+func (l ImageList) partition(images []*Image) (in, out []*Image) {
+	list, _ := l.Get()
+	for _, v := range list {
+		if slices.Contains(images, v) {
+			in = append(in, v)
+		} else {
+			out = append(out, v)
+		}
+	}
+	return in, out
+}
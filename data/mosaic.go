@@ -0,0 +1,169 @@
+package data
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/draw"
+)
+
+// Mosaic arranges the list row by row in a grid of Columns cells instead
+// of a single strip. Each cell is placed against its left neighbor, or
+// the cell above it when it starts a row, by matching the pixel columns or
+// rows they share. Cells without a detectable overlap are placed edge to
+// edge. Separators are not drawn in a mosaic.
+type Mosaic struct {
+	Enabled bool
+	Columns int
+}
+
+var DefaultMosaic = Mosaic{Columns: 2}
+
+// minMosaicVotes is the number of matching rows or columns needed to
+// accept an overlap between two cells.
+const minMosaicVotes = 8
+
+func (m Mosaic) active() bool {
+	return m.Enabled && m.Columns > 0
+}
+
+// mosaicCache keeps the last layout, which is read from the UI and from
+// export goroutines alike.
+type mosaicCache struct {
+	mu    sync.Mutex
+	key   string
+	rects []image.Rectangle
+}
+
+func (l ImageList) mosaicLayout(m Mosaic) []image.Rectangle {
+	list, _ := l.Get()
+	var key strings.Builder
+	fmt.Fprint(&key, m.Columns)
+	for _, v := range list {
+		c, _ := v.Crop.Get()
+		t, _ := v.Transform.Get()
+		fmt.Fprintf(&key, "|%p %v %v", v, c, t)
+		// Trim renders redactions, which change the hashes that place cells.
+		redactions, _ := v.Redactions.Get()
+		for _, r := range redactions {
+			fmt.Fprintf(&key, " %v", *r)
+		}
+	}
+	l.mosaic.mu.Lock()
+	defer l.mosaic.mu.Unlock()
+	if l.mosaic.key == key.String() && len(l.mosaic.rects) == len(list) {
+		return slices.Clone(l.mosaic.rects)
+	}
+
+	images := make([]image.Image, len(list))
+	for i, v := range list {
+		images[i] = v.Trim()
+	}
+	rects := make([]image.Rectangle, len(list))
+	for i, img := range images {
+		size := img.Bounds().Size()
+		var pos image.Point
+		switch c := i % m.Columns; {
+		case c > 0:
+			left := rects[i-1]
+			dx, ok := bestOffset(columnHashes(images[i-1]), columnHashes(img))
+			if !ok {
+				dx = left.Dx()
+			}
+			pos = left.Min.Add(image.Pt(dx, 0))
+		case i > 0:
+			up := rects[i-m.Columns]
+			dy, ok := bestOffset(rowHashes(images[i-m.Columns]), rowHashes(img))
+			if !ok {
+				dy = up.Dy()
+			}
+			pos = up.Min.Add(image.Pt(0, dy))
+		}
+		rects[i] = image.Rectangle{pos, pos.Add(size)}
+	}
+
+	l.mosaic.key, l.mosaic.rects = key.String(), slices.Clone(rects)
+	return rects
+}
+
+// bestOffset finds where line 0 of b lies in a, judging by the offset most
+// matching lines agree on.
+func bestOffset(a, b []uint64) (int, bool) {
+	index := indexRows(a)
+	votes := make(map[int]int)
+	for y, h := range b {
+		if h == 0 {
+			continue
+		}
+		ys := index[h]
+		if len(ys) > maxRowRepeats {
+			continue
+		}
+		for _, v := range ys {
+			if v > y {
+				votes[v-y]++
+			}
+		}
+	}
+	best, score := 0, 0
+	for d, v := range votes {
+		if v > score || v == score && d > best {
+			best, score = d, v
+		}
+	}
+	return best, score >= minMosaicVotes
+}
+
+func columnHashes(img image.Image) []uint64 {
+	b := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+	}
+
+	hashes := make([]uint64, b.Dx())
+	h := fnv.New64a()
+	buf := make([]byte, b.Dy()*4)
+	for x := range hashes {
+		uniform := true
+		for y := 0; y < b.Dy(); y++ {
+			p := rgba.Pix[rgba.PixOffset(b.Min.X+x, b.Min.Y+y):]
+			for ch := range 4 {
+				buf[y*4+ch] = p[ch] & 0xf8
+				if y > 0 && buf[y*4+ch] != buf[ch] {
+					uniform = false
+				}
+			}
+		}
+		if uniform {
+			continue
+		}
+		h.Reset()
+		h.Write(buf)
+		hashes[x] = h.Sum64()
+	}
+	return hashes
+}
+
+// InferMosaicColumns guesses the grid width as the number of leading
+// images that each overlap horizontally with the one before.
+func (l ImageList) InferMosaicColumns() int {
+	list, _ := l.Get()
+	if len(list) == 0 {
+		return 1
+	}
+	prev := columnHashes(list[0].Trim())
+	for i := 1; i < len(list); i++ {
+		cols := columnHashes(list[i].Trim())
+		if _, ok := bestOffset(prev, cols); !ok {
+			return i
+		}
+		prev = cols
+	}
+	return len(list)
+}
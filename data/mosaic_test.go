@@ -0,0 +1,108 @@
+package data
+
+import (
+	"image"
+	"slices"
+	"testing"
+)
+
+// noise returns an image of pseudo-random pixels, so that every row and
+// column of it is distinct.
+func noise(w, h int, seed uint32) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint8(seed >> 24)
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+func tile(src *image.NRGBA, r image.Rectangle) *Image {
+	dst := image.NewNRGBA(image.Rectangle{Max: r.Size()})
+	for y := range r.Dy() {
+		copy(dst.Pix[y*dst.Stride:][:r.Dx()*4], src.Pix[src.PixOffset(r.Min.X, r.Min.Y+y):])
+	}
+	return NewImage(nil, dst)
+}
+
+func mosaicList(images ...*Image) ImageList {
+	l := NewImageList()
+	l.Set(images)
+	l.Mosaic.Set(Mosaic{Enabled: true, Columns: 2})
+	return l
+}
+
+func TestMosaicLayout(t *testing.T) {
+	src := noise(60, 60, 1)
+	overlapping := []*Image{
+		tile(src, image.Rect(0, 0, 40, 40)),
+		tile(src, image.Rect(20, 0, 60, 40)),
+		tile(src, image.Rect(0, 20, 40, 60)),
+		tile(src, image.Rect(20, 20, 60, 60)),
+	}
+	separate := []*Image{
+		NewImage(nil, noise(40, 30, 2)),
+		NewImage(nil, noise(40, 30, 3)),
+		NewImage(nil, noise(40, 30, 4)),
+	}
+	tests := []struct {
+		name    string
+		images  []*Image
+		want    []image.Rectangle
+		columns int
+	}{
+		{"overlapping grid", overlapping, []image.Rectangle{
+			image.Rect(0, 0, 40, 40), image.Rect(20, 0, 60, 40),
+			image.Rect(0, 20, 40, 60), image.Rect(20, 20, 60, 60),
+		}, 2},
+		{"edge to edge", separate, []image.Rectangle{
+			image.Rect(0, 0, 40, 30), image.Rect(40, 0, 80, 30),
+			image.Rect(0, 30, 40, 60),
+		}, 1},
+	}
+	for _, tt := range tests {
+		l := mosaicList(tt.images...)
+		if got := l.Layout(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Layout() = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := l.InferMosaicColumns(); got != tt.columns {
+			t.Errorf("%s: InferMosaicColumns() = %d, want %d", tt.name, got, tt.columns)
+		}
+	}
+}
+
+func TestMosaicLayoutRedaction(t *testing.T) {
+	src := noise(60, 40, 5)
+	right := tile(src, image.Rect(20, 0, 60, 40))
+	l := mosaicList(tile(src, image.Rect(0, 0, 40, 40)), right)
+	if got, want := l.Layout()[1], image.Rect(20, 0, 60, 40); got != want {
+		t.Fatalf("Layout()[1] = %v, want %v", got, want)
+	}
+
+	// Blacking out the overlap leaves nothing to match, so the cached
+	// placement must not be reused.
+	right.Redactions.Set([]*Redaction{{Rect: image.Rect(0, 0, 20, 40), Style: RedactFill}})
+	if got, want := l.Layout()[1], image.Rect(40, 0, 80, 40); got != want {
+		t.Errorf("Layout()[1] after redaction = %v, want %v", got, want)
+	}
+}
+
+func TestBestOffset(t *testing.T) {
+	a := make([]uint64, 30)
+	for i := range a {
+		a[i] = uint64(i + 1)
+	}
+	if d, ok := bestOffset(a, a[12:]); !ok || d != 12 {
+		t.Errorf("bestOffset() = %d, %v, want 12, true", d, ok)
+	}
+	if _, ok := bestOffset(a, a[25:]); ok {
+		t.Error("bestOffset() accepted 5 matching lines")
+	}
+	blank := make([]uint64, 20)
+	if _, ok := bestOffset(blank, blank); ok {
+		t.Error("bestOffset() matched uniform lines")
+	}
+}
@@ -0,0 +1,405 @@
+package data
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"slices"
+
+	"golang.org/x/image/draw"
+)
+
+// PNGOptimization controls how PNG output is shrunk. Lossless reduction to
+// grayscale or a palette is always tried; Lossy additionally quantizes the
+// image to at most Colors colors, with Floyd-Steinberg dithering if Dither
+// is set.
+type PNGOptimization struct {
+	Enabled bool
+	Lossy   bool
+	Colors  int
+	Dither  bool
+}
+
+var DefaultPNGOptimization = PNGOptimization{Colors: 256, Dither: true}
+
+type OptimizeReport struct {
+	Format              string
+	Original, Optimized int
+}
+
+func (r OptimizeReport) Saved() int {
+	return r.Original - r.Optimized
+}
+
+func (r OptimizeReport) String() string {
+	if r.Original == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s, %s → %s (%.0f%% smaller)",
+		r.Format, formatBytes(r.Original), formatBytes(r.Optimized),
+		float64(r.Saved())/float64(r.Original)*100)
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+var pngCompressionLevels = []png.CompressionLevel{png.DefaultCompression, png.BestCompression}
+
+func OptimizePNG(img image.Image, opt PNGOptimization) ([]byte, OptimizeReport, error) {
+	return optimizePNG(img, opt, true)
+}
+
+// optimizePNG encodes every candidate representation at each compression
+// level, with and without row filtering, and keeps the smallest. Grayscale
+// is skipped when allowGray is false, since an embedded RGB profile is
+// invalid for a gray PNG.
+func optimizePNG(img image.Image, opt PNGOptimization, allowGray bool) ([]byte, OptimizeReport, error) {
+	type candidate struct {
+		format string
+		img    image.Image
+	}
+	candidates := []candidate{{"Original", img}}
+	if allowGray {
+		if gray := toGray(img); gray != nil {
+			candidates = append(candidates, candidate{"Grayscale", gray})
+		}
+	}
+	if !formatOf(img).deep() {
+		if p := toPaletted(img); p != nil {
+			candidates = append(candidates, candidate{fmt.Sprintf("Palette (%d colors)", len(p.Palette)), p})
+		} else if opt.Lossy {
+			p := quantize(img, min(max(opt.Colors, 2), 256), opt.Dither)
+			candidates = append(candidates, candidate{fmt.Sprintf("Quantized (%d colors)", len(p.Palette)), p})
+		}
+	}
+
+	var best []byte
+	var report OptimizeReport
+	keep := func(b []byte, format string) {
+		if best == nil || len(b) < len(best) {
+			best, report.Format = b, format
+		}
+	}
+	for _, c := range candidates {
+		for _, level := range pngCompressionLevels {
+			var buf bytes.Buffer
+			if err := (&png.Encoder{CompressionLevel: level}).Encode(&buf, c.img); err != nil {
+				return nil, OptimizeReport{}, err
+			}
+			if c.format == "Original" && level == png.DefaultCompression {
+				report.Original = buf.Len()
+			}
+			keep(buf.Bytes(), c.format)
+		}
+		// png.Encoder never filters palettes and always filters everything
+		// else, so each candidate is also tried the other way.
+		raster := newPNGRaster(c.img)
+		for _, f := range pngFilters {
+			b, err := raster.encode(f, png.BestCompression)
+			if err != nil {
+				return nil, OptimizeReport{}, err
+			}
+			keep(b, c.format)
+		}
+	}
+	report.Optimized = len(best)
+	return best, report, nil
+}
+
+// toGray returns img as grayscale, or nil if it has color, transparency
+// or already is grayscale.
+func toGray(img image.Image) image.Image {
+	if formatOf(img).gray() {
+		return nil
+	}
+	b := img.Bounds()
+	if formatOf(img).deep() {
+		src := toNRGBA64(img)
+		gray := image.NewGray16(b)
+		for y := range b.Dy() {
+			pix, dst := src.Pix[y*src.Stride:][:b.Dx()*8], gray.Pix[y*gray.Stride:]
+			for x := range b.Dx() {
+				p := pix[x*8:][:8]
+				if p[0] != p[2] || p[1] != p[3] || p[0] != p[4] || p[1] != p[5] || p[6] != 0xff || p[7] != 0xff {
+					return nil
+				}
+				dst[x*2], dst[x*2+1] = p[0], p[1]
+			}
+		}
+		return gray
+	}
+	src := toNRGBA(img)
+	gray := image.NewGray(b)
+	for y := range b.Dy() {
+		pix, dst := src.Pix[y*src.Stride:][:b.Dx()*4], gray.Pix[y*gray.Stride:]
+		for x := range b.Dx() {
+			p := pix[x*4:][:4]
+			if p[0] != p[1] || p[1] != p[2] || p[3] != 0xff {
+				return nil
+			}
+			dst[x] = p[0]
+		}
+	}
+	return gray
+}
+
+// toPaletted returns img with a palette of its own colors, or nil if it
+// has more than 256.
+func toPaletted(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	src := toNRGBA(img)
+	index := map[uint32]uint8{}
+	var palette color.Palette
+	p := image.NewPaletted(b, nil)
+	for y := range b.Dy() {
+		pix, dst := src.Pix[y*src.Stride:][:b.Dx()*4], p.Pix[y*p.Stride:]
+		for x := range b.Dx() {
+			c := pix[x*4:][:4]
+			key := binary.BigEndian.Uint32(c)
+			i, ok := index[key]
+			if !ok {
+				if len(palette) == 256 {
+					return nil
+				}
+				i = uint8(len(palette))
+				index[key] = i
+				palette = append(palette, color.NRGBA{c[0], c[1], c[2], c[3]})
+			}
+			dst[x] = i
+		}
+	}
+	p.Palette = palette
+	return p
+}
+
+// toNRGBA returns img with straight 8-bit alpha, with its bounds moved to
+// the origin.
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if n, ok := img.(*image.NRGBA); ok && b.Min == (image.Point{}) {
+		return n
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	switch src := img.(type) {
+	case *image.NRGBA:
+		for y := range b.Dy() {
+			copy(dst.Pix[y*dst.Stride:][:b.Dx()*4], src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):])
+		}
+	case *image.RGBA:
+		for y := range b.Dy() {
+			pix, out := src.Pix[src.PixOffset(b.Min.X, b.Min.Y+y):][:b.Dx()*4], dst.Pix[y*dst.Stride:]
+			for i := 0; i < len(pix); i += 4 {
+				switch a := pix[i+3]; a {
+				case 0xff:
+					copy(out[i:i+4], pix[i:i+4])
+				case 0:
+					// Fully transparent pixels stay zero.
+				default:
+					out[i] = uint8(int(pix[i]) * 0xff / int(a))
+					out[i+1] = uint8(int(pix[i+1]) * 0xff / int(a))
+					out[i+2] = uint8(int(pix[i+2]) * 0xff / int(a))
+					out[i+3] = a
+				}
+			}
+		}
+	default:
+		draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	}
+	return dst
+}
+
+// toNRGBA64 is toNRGBA at 16 bits per channel.
+func toNRGBA64(img image.Image) *image.NRGBA64 {
+	b := img.Bounds()
+	if n, ok := img.(*image.NRGBA64); ok && b.Min == (image.Point{}) {
+		return n
+	}
+	dst := image.NewNRGBA64(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+const quantizeBits = 5
+
+type colorBox struct {
+	colors []weightedColor
+}
+
+type weightedColor struct {
+	c      [4]int
+	weight int
+}
+
+func (b colorBox) widest() (channel, span int) {
+	for ch := range 4 {
+		lo, hi := 255, 0
+		for _, v := range b.colors {
+			lo, hi = min(lo, v.c[ch]), max(hi, v.c[ch])
+		}
+		if hi-lo > span {
+			channel, span = ch, hi-lo
+		}
+	}
+	return channel, span
+}
+
+func (b colorBox) average() color.NRGBA {
+	var sum [4]int
+	total := 0
+	for _, v := range b.colors {
+		for ch := range 4 {
+			sum[ch] += v.c[ch] * v.weight
+		}
+		total += v.weight
+	}
+	return color.NRGBA{
+		uint8(sum[0] / total), uint8(sum[1] / total), uint8(sum[2] / total), uint8(sum[3] / total),
+	}
+}
+
+// quantize reduces img to at most n colors with median cut over a
+// 5-bit-per-channel histogram.
+func quantize(img image.Image, n int, dither bool) *image.Paletted {
+	b := img.Bounds()
+	src := image.NewNRGBA(b)
+	draw.Draw(src, b, img, b.Min, draw.Src)
+
+	const shift = 8 - quantizeBits
+	histogram := map[[4]int]int{}
+	for i := 0; i < len(src.Pix); i += 4 {
+		var key [4]int
+		for ch := range 4 {
+			key[ch] = int(src.Pix[i+ch]) >> shift
+		}
+		histogram[key]++
+	}
+	colors := make([]weightedColor, 0, len(histogram))
+	for k, w := range histogram {
+		var c [4]int
+		for ch := range 4 {
+			c[ch] = k[ch]<<shift | k[ch]>>(quantizeBits-shift)
+		}
+		colors = append(colors, weightedColor{c, w})
+	}
+
+	boxes := []colorBox{{colors}}
+	for len(boxes) < n {
+		split, channel, span := -1, 0, 0
+		for i, box := range boxes {
+			if len(box.colors) < 2 {
+				continue
+			}
+			if ch, s := box.widest(); s > span {
+				split, channel, span = i, ch, s
+			}
+		}
+		if split < 0 {
+			break
+		}
+		box := boxes[split]
+		slices.SortFunc(box.colors, func(a, b weightedColor) int { return cmp.Compare(a.c[channel], b.c[channel]) })
+		total := 0
+		for _, v := range box.colors {
+			total += v.weight
+		}
+		mid, acc := 1, 0
+		for i, v := range box.colors[:len(box.colors)-1] {
+			acc += v.weight
+			if acc*2 >= total {
+				mid = i + 1
+				break
+			}
+		}
+		boxes[split] = colorBox{box.colors[:mid]}
+		boxes = append(boxes, colorBox{box.colors[mid:]})
+	}
+
+	palette := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		palette[i] = box.average()
+	}
+	dst := image.NewPaletted(b, palette)
+	nearest := newNearestCache(palette)
+
+	if !dither {
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				p := src.Pix[y*src.Stride+x*4:]
+				dst.Pix[y*dst.Stride+x] = nearest.index([4]int{int(p[0]), int(p[1]), int(p[2]), int(p[3])})
+			}
+		}
+		return dst
+	}
+
+	cur := make([][4]int, b.Dx()+2)
+	next := make([][4]int, b.Dx()+2)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			p := src.Pix[y*src.Stride+x*4:]
+			var c [4]int
+			for ch := range 4 {
+				c[ch] = min(max(int(p[ch])+cur[x+1][ch]/16, 0), 255)
+			}
+			i := nearest.index(c)
+			dst.Pix[y*dst.Stride+x] = i
+			q := palette[i].(color.NRGBA)
+			e := [4]int{c[0] - int(q.R), c[1] - int(q.G), c[2] - int(q.B), c[3] - int(q.A)}
+			for ch := range 4 {
+				cur[x+2][ch] += e[ch] * 7
+				next[x][ch] += e[ch] * 3
+				next[x+1][ch] += e[ch] * 5
+				next[x+2][ch] += e[ch] * 1
+			}
+		}
+		cur, next = next, cur
+		clear(next)
+	}
+	return dst
+}
+
+type nearestCache struct {
+	palette color.Palette
+	cache   []int16
+}
+
+func newNearestCache(palette color.Palette) *nearestCache {
+	cache := make([]int16, 1<<(quantizeBits*4))
+	for i := range cache {
+		cache[i] = -1
+	}
+	return &nearestCache{palette, cache}
+}
+
+func (n *nearestCache) index(c [4]int) uint8 {
+	const shift = 8 - quantizeBits
+	key := c[0]>>shift<<(quantizeBits*3) | c[1]>>shift<<(quantizeBits*2) | c[2]>>shift<<quantizeBits | c[3]>>shift
+	if v := n.cache[key]; v >= 0 {
+		return uint8(v)
+	}
+	best, dist := 0, -1
+	for i, p := range n.palette {
+		q := p.(color.NRGBA)
+		d := sq(c[0]-int(q.R)) + sq(c[1]-int(q.G)) + sq(c[2]-int(q.B)) + sq(c[3]-int(q.A))
+		if dist < 0 || d < dist {
+			best, dist = i, d
+		}
+	}
+	n.cache[key] = int16(best)
+	return uint8(best)
+}
+
+func sq(v int) int {
+	return v * v
+}
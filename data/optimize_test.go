@@ -0,0 +1,142 @@
+package data
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func samePixels(t *testing.T, want, got image.Image) {
+	t.Helper()
+	if want.Bounds().Size() != got.Bounds().Size() {
+		t.Fatalf("size = %v, want %v", got.Bounds().Size(), want.Bounds().Size())
+	}
+	wb, gb := want.Bounds(), got.Bounds()
+	for y := range wb.Dy() {
+		for x := range wb.Dx() {
+			r0, g0, b0, a0 := want.At(wb.Min.X+x, wb.Min.Y+y).RGBA()
+			r1, g1, b1, a1 := got.At(gb.Min.X+x, gb.Min.Y+y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				t.Fatalf("pixel (%d, %d) = %v, want %v", x, y,
+					[4]uint32{r1, g1, b1, a1}, [4]uint32{r0, g0, b0, a0})
+			}
+		}
+	}
+}
+
+func gradient(colors int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(3, 5, 43, 35))
+	for y := range 30 {
+		for x := range 40 {
+			v := uint8((x + y) % colors * (256 / colors))
+			c := color.NRGBA{v, 0xff - v, v / 2, 0xff}
+			if alpha && x%3 == 0 {
+				c.A = 0x80
+			}
+			img.SetNRGBA(3+x, 5+y, c)
+		}
+	}
+	return img
+}
+
+func TestPNGRasterRoundTrip(t *testing.T) {
+	gray16 := image.NewGray16(image.Rect(0, 0, 7, 3))
+	for i := range gray16.Pix {
+		gray16.Pix[i] = uint8(i * 37)
+	}
+	gray := image.NewGray(image.Rect(0, 0, 5, 5))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i * 9)
+	}
+	rgba := image.NewRGBA(image.Rect(1, 1, 9, 9))
+	for i := range rgba.Pix {
+		rgba.Pix[i] = uint8(i * 13)
+	}
+	for i := 3; i < len(rgba.Pix); i += 4 {
+		rgba.Pix[i] = 0xff
+	}
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"opaque", gradient(200, false)},
+		{"alpha", gradient(200, true)},
+		{"rgba", rgba},
+		{"gray", gray},
+		{"gray16", gray16},
+		{"palette 2", toPaletted(gradient(2, false))},
+		{"palette 3", toPaletted(gradient(3, true))},
+		{"palette 16", toPaletted(gradient(16, false))},
+		{"palette 64", toPaletted(gradient(64, false))},
+	}
+	for _, tt := range tests {
+		raster := newPNGRaster(tt.img)
+		for f := filterNone; f <= filterAdaptive; f++ {
+			b, err := raster.encode(f, png.BestCompression)
+			if err != nil {
+				t.Fatalf("%s, filter %d: %v", tt.name, f, err)
+			}
+			got, err := png.Decode(bytes.NewReader(b))
+			if err != nil {
+				t.Fatalf("%s, filter %d: %v", tt.name, f, err)
+			}
+			samePixels(t, tt.img, got)
+		}
+	}
+}
+
+func TestToGray(t *testing.T) {
+	img := image.NewRGBA(image.Rect(2, 2, 6, 6))
+	for y := 2; y < 6; y++ {
+		for x := 2; x < 6; x++ {
+			v := uint8(x * y * 10)
+			img.SetRGBA(x, y, color.RGBA{v, v, v, 0xff})
+		}
+	}
+	gray := toGray(img)
+	if gray == nil {
+		t.Fatal("toGray() = nil for a gray image")
+	}
+	samePixels(t, img, gray)
+
+	img.SetRGBA(3, 3, color.RGBA{1, 2, 3, 0xff})
+	if toGray(img) != nil {
+		t.Error("toGray() accepted a colored image")
+	}
+}
+
+func TestToPaletted(t *testing.T) {
+	img := gradient(16, true)
+	p := toPaletted(img)
+	if p == nil {
+		t.Fatal("toPaletted() = nil for 32 colors")
+	}
+	samePixels(t, img, p)
+	many := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := range 20 {
+		for x := range 20 {
+			many.SetNRGBA(x, y, color.NRGBA{uint8(x * 12), uint8(y * 12), 0, 0xff})
+		}
+	}
+	if toPaletted(many) != nil {
+		t.Error("toPaletted() accepted more than 256 colors")
+	}
+}
+
+func TestOptimizePNG(t *testing.T) {
+	img := gradient(8, false)
+	b, report, err := OptimizePNG(img, DefaultPNGOptimization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Optimized != len(b) || report.Optimized > report.Original {
+		t.Errorf("report = %+v for %d bytes", report, len(b))
+	}
+	got, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samePixels(t, img, got)
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"cmp"
+	"hash/fnv"
+	"image"
+	"slices"
+
+	"golang.org/x/image/draw"
+)
+
+const maxRowRepeats = 8
+
+func (l ImageList) SortByContent() error {
+	list, _ := l.Get()
+	rows := make([][]uint64, len(list))
+	for i, v := range list {
+		rows[i] = rowHashes(v.Source())
+	}
+
+	type edge struct{ from, to, score int }
+	var edges []edge
+	for a := range list {
+		index := indexRows(rows[a])
+		for b := range list {
+			if a == b {
+				continue
+			}
+			if score := overlapScore(index, rows[b]); score > 0 {
+				edges = append(edges, edge{a, b, score})
+			}
+		}
+	}
+	slices.SortStableFunc(edges, func(x, y edge) int { return cmp.Compare(y.score, x.score) })
+
+	next := make([]int, len(list))
+	prev := make([]int, len(list))
+	for i := range list {
+		next[i], prev[i] = -1, -1
+	}
+	head := func(i int) int {
+		for prev[i] >= 0 {
+			i = prev[i]
+		}
+		return i
+	}
+	for _, e := range edges {
+		if next[e.from] >= 0 || prev[e.to] >= 0 || head(e.from) == e.to {
+			continue
+		}
+		next[e.from], prev[e.to] = e.to, e.from
+	}
+
+	sorted := make([]*Image, 0, len(list))
+	for i := range list {
+		if prev[i] >= 0 {
+			continue
+		}
+		for j := i; j >= 0; j = next[j] {
+			sorted = append(sorted, list[j])
+		}
+	}
+	return l.Commit(sorted)
+}
+
+func rowHashes(img image.Image) []uint64 {
+	b := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+	}
+
+	hashes := make([]uint64, b.Dy())
+	h := fnv.New64a()
+	buf := make([]byte, b.Dx()*4)
+	for y := range hashes {
+		row := rgba.Pix[y*rgba.Stride : y*rgba.Stride+len(buf)]
+		uniform := true
+		for i, v := range row {
+			buf[i] = v & 0xf8
+			if i >= 4 && buf[i] != buf[i%4] {
+				uniform = false
+			}
+		}
+		if uniform {
+			continue
+		}
+		h.Reset()
+		h.Write(buf)
+		hashes[y] = h.Sum64()
+	}
+	return hashes
+}
+
+func indexRows(rows []uint64) map[uint64][]int {
+	index := make(map[uint64][]int)
+	for y, h := range rows {
+		if h != 0 {
+			index[h] = append(index[h], y)
+		}
+	}
+	return index
+}
+
+func overlapScore(index map[uint64][]int, rows []uint64) int {
+	votes := make(map[int]int)
+	for y, h := range rows {
+		if h == 0 {
+			continue
+		}
+		ys := index[h]
+		if len(ys) > maxRowRepeats {
+			continue
+		}
+		for _, v := range ys {
+			if v > y {
+				votes[v-y]++
+			}
+		}
+	}
+	score := 0
+	for _, v := range votes {
+		score = max(score, v)
+	}
+	return score
+}
@@ -0,0 +1,170 @@
+package data
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"golang.org/x/image/draw"
+)
+
+type Resampler int
+
+const (
+	ResampleNearest Resampler = iota
+	ResampleBilinear
+	ResampleCatmullRom
+)
+
+var Resamplers = []Resampler{ResampleNearest, ResampleBilinear, ResampleCatmullRom}
+
+func (r Resampler) String() string {
+	switch r {
+	case ResampleNearest:
+		return "Nearest"
+	case ResampleBilinear:
+		return "Bilinear"
+	case ResampleCatmullRom:
+		return "Catmull-Rom"
+	default:
+		return ""
+	}
+}
+
+func (r Resampler) interpolator() draw.Interpolator {
+	switch r {
+	case ResampleNearest:
+		return draw.NearestNeighbor
+	case ResampleBilinear:
+		return draw.BiLinear
+	default:
+		return draw.CatmullRom
+	}
+}
+
+// Output controls the pixel size of the saved image. Scale is applied
+// first, then Width, then the MaxWidth, MaxHeight and MaxPixels limits.
+// Zero values leave the size unchanged. When Retina is set, a second
+// image with an @2x suffix is written next to the saved one, at twice the
+// output size but never above the source resolution, and the saved image
+// is scaled down from it. EmbedProfile embeds the list's working color
+// space as an ICC profile. Optimize shrinks PNG files and PDF configures
+// PDF export. LayoutMap writes a JSON sidecar describing where each item
+// landed.
+type Output struct {
+	Scale        float64
+	Width        int
+	MaxWidth     int
+	MaxHeight    int
+	MaxPixels    int
+	Resampler    Resampler
+	Retina       bool
+	EmbedProfile bool
+	Optimize     PNGOptimization
+	PDF          PDFOptions
+	LayoutMap    bool
+}
+
+var DefaultOutput = Output{Resampler: ResampleCatmullRom, Optimize: DefaultPNGOptimization, PDF: DefaultPDFOptions}
+
+const retinaSuffix = "@2x"
+
+func (o Output) Size(size image.Point) image.Point {
+	w, h := float64(size.X), float64(size.Y)
+	if w == 0 || h == 0 {
+		return size
+	}
+	s := 1.0
+	if o.Scale > 0 {
+		s = o.Scale
+	}
+	if o.Width > 0 {
+		s = float64(o.Width) / w
+	}
+	if o.MaxWidth > 0 {
+		s = min(s, float64(o.MaxWidth)/w)
+	}
+	if o.MaxHeight > 0 {
+		s = min(s, float64(o.MaxHeight)/h)
+	}
+	if o.MaxPixels > 0 {
+		s = min(s, math.Sqrt(float64(o.MaxPixels)/(w*h)))
+	}
+	return image.Pt(max(int(math.Round(w*s)), 1), max(int(math.Round(h*s)), 1))
+}
+
+// RetinaSize returns the size of the @2x image for a source of the given
+// size. The saved image keeps Size either way.
+func (o Output) RetinaSize(size image.Point) image.Point {
+	if r := o.Size(size).Mul(2); r.X <= size.X && r.Y <= size.Y {
+		return r
+	}
+	return size
+}
+
+func (o Output) Apply(img image.Image) image.Image {
+	return o.resize(img, o.Size(img.Bounds().Size()))
+}
+
+func (o Output) resize(img image.Image, size image.Point) image.Image {
+	b := img.Bounds()
+	if size == b.Size() || size.X <= 0 || size.Y <= 0 {
+		return img
+	}
+	dst := formatOf(img).New(image.Rectangle{Max: size})
+	o.Resampler.interpolator().Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func retinaURI(uri fyne.URI) (fyne.URI, error) {
+	parent, err := storage.Parent(uri)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(uri.Name(), uri.Extension())
+	return storage.Child(parent, name+retinaSuffix+uri.Extension())
+}
+
+func (l ImageList) RenderSize() image.Point {
+	return l.renderSize(l.sizeColumns())
+}
+
+// StampedSize is RenderSize with the footer added, which is the size the
+// output settings scale.
+func (l ImageList) StampedSize() image.Point {
+	return l.stampedSize(l.sizeColumns())
+}
+
+// sizeColumns composes the list only when columns are on, since the
+// column height depends on its content.
+func (l ImageList) sizeColumns() *ColumnLayout {
+	if columns, _ := l.Columns.Get(); columns.IsZero() || l.Length() == 0 {
+		return nil
+	}
+	return l.columnLayout(l.Compose())
+}
+
+func (l ImageList) renderSize(columns *ColumnLayout) image.Point {
+	var size image.Point
+	for _, r := range l.Layout() {
+		size = image.Pt(max(size.X, r.Max.X), max(size.Y, r.Max.Y))
+	}
+	if columns != nil && size.Y > 0 {
+		size = columns.Size()
+	}
+	decoration, _ := l.Decoration.Get()
+	if !decoration.IsZero() {
+		size = decoration.Size(size)
+	}
+	return size
+}
+
+func (l ImageList) stampedSize(columns *ColumnLayout) image.Point {
+	size := l.renderSize(columns)
+	if stamp, _ := l.Stamp.Get(); stamp.Footer.Enabled && size.Y > 0 {
+		size.Y += stamp.Footer.height()
+	}
+	return size
+}
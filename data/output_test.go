@@ -0,0 +1,29 @@
+package data
+
+import (
+	"image"
+	"testing"
+)
+
+func TestOutputRetinaSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       Output
+		src          image.Point
+		size, retina image.Point
+	}{
+		{"unchanged", Output{Retina: true}, image.Pt(1000, 3000), image.Pt(1000, 3000), image.Pt(1000, 3000)},
+		{"half", Output{Retina: true, Scale: 0.5}, image.Pt(1000, 3000), image.Pt(500, 1500), image.Pt(1000, 3000)},
+		{"quarter", Output{Retina: true, Scale: 0.25}, image.Pt(1000, 3000), image.Pt(250, 750), image.Pt(500, 1500)},
+		{"width above half the source", Output{Retina: true, Width: 1080}, image.Pt(1500, 3000), image.Pt(1080, 2160), image.Pt(1500, 3000)},
+		{"max width", Output{Retina: true, MaxWidth: 600}, image.Pt(1500, 3000), image.Pt(600, 1200), image.Pt(1200, 2400)},
+	}
+	for _, tt := range tests {
+		if got := tt.output.Size(tt.src); got != tt.size {
+			t.Errorf("%s: Size() = %v, want %v", tt.name, got, tt.size)
+		}
+		if got := tt.output.RetinaSize(tt.src); got != tt.retina {
+			t.Errorf("%s: RetinaSize() = %v, want %v", tt.name, got, tt.retina)
+		}
+	}
+}
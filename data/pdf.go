@@ -0,0 +1,356 @@
+package data
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+type PaperSize int
+
+const (
+	PaperA4 PaperSize = iota
+	PaperA3
+	PaperLetter
+	PaperLegal
+)
+
+var PaperSizes = []PaperSize{PaperA4, PaperA3, PaperLetter, PaperLegal}
+
+func (p PaperSize) String() string {
+	switch p {
+	case PaperA4:
+		return "A4"
+	case PaperA3:
+		return "A3"
+	case PaperLetter:
+		return "Letter"
+	case PaperLegal:
+		return "Legal"
+	default:
+		return ""
+	}
+}
+
+// Points returns the page width and height in PDF points.
+func (p PaperSize) Points() (float64, float64) {
+	switch p {
+	case PaperA3:
+		return 842, 1191
+	case PaperLetter:
+		return 612, 792
+	case PaperLegal:
+		return 612, 1008
+	default:
+		return 595, 842
+	}
+}
+
+// PDFOptions controls PDF export. The exported image is scaled to the page
+// width inside Margin (in millimeters) and split across as many pages as
+// needed, preferring breaks between source images. Pages are embedded
+// losslessly unless JPEG is set.
+type PDFOptions struct {
+	Paper       PaperSize
+	Margin      float64
+	JPEG        bool
+	Quality     int
+	PageNumbers bool
+	TitlePage   bool
+}
+
+var DefaultPDFOptions = PDFOptions{Margin: 10, Quality: 90, PageNumbers: true}
+
+const pointsPerMM = 72 / 25.4
+
+// pageBreaks splits [0, height) into page ranges of at most pageHeight
+// pixels. A page ends at the last clean image boundary that fills at least
+// a quarter of it; otherwise it is cut at pageHeight.
+func pageBreaks(rects []image.Rectangle, height, pageHeight int) [][2]int {
+	breaks := layoutBreaks(rects)
+	var pages [][2]int
+	for y := 0; y < height; {
+		limit := min(y+pageHeight, height)
+		end, next := limit, limit
+		if limit < height {
+			for _, v := range breaks {
+				if v.End > limit {
+					break
+				}
+				if v.End > y+pageHeight/4 && v.Next > y {
+					end, next = v.End, v.Next
+				}
+			}
+		}
+		pages = append(pages, [2]int{y, end})
+		y = next
+	}
+	return pages
+}
+
+// layoutBreaks returns the bottoms of the layout rows, sorted and without
+// duplicates, that no item straddles. Each break continues at the top of
+// the next item below it, skipping any separator in between.
+func layoutBreaks(rects []image.Rectangle) []columnBreak {
+	var ends []int
+	for _, r := range rects {
+		ends = append(ends, r.Max.Y)
+	}
+	slices.Sort(ends)
+	ends = slices.Compact(ends)
+
+	var breaks []columnBreak
+	for _, end := range ends {
+		next, clean := -1, true
+		for _, r := range rects {
+			if r.Min.Y < end && end < r.Max.Y {
+				clean = false
+				break
+			}
+			if r.Min.Y >= end && (next < 0 || r.Min.Y < next) {
+				next = r.Min.Y
+			}
+		}
+		if !clean {
+			continue
+		}
+		if next < 0 {
+			next = end
+		}
+		breaks = append(breaks, columnBreak{end, next})
+	}
+	return breaks
+}
+
+func (l ImageList) WritePDF(w io.Writer, title string) error {
+	opts, _ := l.Output.Get()
+	pdf := opts.PDF
+	render, columns := l.render()
+	img := opts.Apply(l.stamp(render))
+	b := img.Bounds()
+	if b.Empty() {
+		return ErrEmptyList
+	}
+	var rects []image.Rectangle
+	for _, v := range l.layoutMap(columns).Images {
+		for _, r := range append([]LayoutRect{v.Rect}, v.Parts...) {
+			rects = append(rects, image.Rect(r.X, r.Y, r.X+r.Width, r.Y+r.Height))
+		}
+	}
+
+	pw, ph := pdf.Paper.Points()
+	margin := pdf.Margin * pointsPerMM
+	cw, ch := pw-margin*2, ph-margin*2
+	if pdf.PageNumbers {
+		ch -= 16
+	}
+	scale := cw / float64(b.Dx())
+	pages := pageBreaks(rects, b.Dy(), max(int(ch/scale), 1))
+
+	p := newPDFWriter(w)
+	catalog, pagesRef, font := p.reserve(), p.reserve(), p.reserve()
+	p.object(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	var kids []int
+	total := len(pages)
+	if pdf.TitlePage {
+		total++
+	}
+	addPage := func(content string, xobjects map[string]int) {
+		stream := p.stream(nil, []byte(content))
+		var res strings.Builder
+		res.WriteString(fmt.Sprintf("<< /Font << /F1 %d 0 R >>", font))
+		if len(xobjects) > 0 {
+			res.WriteString(" /XObject <<")
+			for name, ref := range xobjects {
+				res.WriteString(fmt.Sprintf(" /%s %d 0 R", name, ref))
+			}
+			res.WriteString(" >>")
+		}
+		res.WriteString(" >>")
+		page := p.reserve()
+		p.object(page, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %g %g] /Resources %s /Contents %d 0 R >>",
+			pagesRef, pw, ph, res.String(), stream))
+		kids = append(kids, page)
+	}
+	footer := func(n int) string {
+		if !pdf.PageNumbers {
+			return ""
+		}
+		text := fmt.Sprintf("%d / %d", n, total)
+		return fmt.Sprintf("BT /F1 9 Tf %.2f %.2f Td (%s) Tj ET\n", pw/2-float64(len(text))*2.5, margin, pdfString(text))
+	}
+
+	if pdf.TitlePage {
+		var c strings.Builder
+		y := ph - margin - 24
+		c.WriteString(fmt.Sprintf("BT /F1 20 Tf %.2f %.2f Td (%s) Tj ET\n", margin, y, pdfString(title)))
+		y -= 32
+		list, _ := l.Get()
+		for _, v := range list {
+			if v.Generated != nil {
+				continue
+			}
+			if y < margin+24 {
+				break
+			}
+			c.WriteString(fmt.Sprintf("BT /F1 11 Tf %.2f %.2f Td (%s) Tj ET\n", margin, y, pdfString(v.Name())))
+			y -= 16
+		}
+		c.WriteString(footer(1))
+		addPage(c.String(), nil)
+	}
+
+	for i, r := range pages {
+		sub := image.Rect(b.Min.X, b.Min.Y+r[0], b.Max.X, b.Min.Y+r[1])
+		ref, err := p.image(img, sub, pdf)
+		if err != nil {
+			return err
+		}
+		w, h := float64(sub.Dx())*scale, float64(sub.Dy())*scale
+		content := fmt.Sprintf("q %.4f 0 0 %.4f %.2f %.2f cm /Im0 Do Q\n", w, h, margin, ph-margin-h) + footer(total-len(pages)+i+1)
+		addPage(content, map[string]int{"Im0": ref})
+	}
+
+	refs := make([]string, len(kids))
+	for i, k := range kids {
+		refs[i] = fmt.Sprintf("%d 0 R", k)
+	}
+	p.object(pagesRef, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(refs, " "), len(kids)))
+	p.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesRef))
+	info := p.reserve()
+	p.object(info, fmt.Sprintf("<< /Title (%s) /Producer (rollshot) >>", pdfString(title)))
+	return p.close(catalog, info)
+}
+
+type pdfWriter struct {
+	w       io.Writer
+	n       int
+	offsets []int
+	err     error
+}
+
+func newPDFWriter(w io.Writer) *pdfWriter {
+	p := &pdfWriter{w: w}
+	p.write([]byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"))
+	return p
+}
+
+func (p *pdfWriter) write(b []byte) {
+	if p.err != nil {
+		return
+	}
+	n, err := p.w.Write(b)
+	p.n += n
+	p.err = err
+}
+
+func (p *pdfWriter) reserve() int {
+	p.offsets = append(p.offsets, -1)
+	return len(p.offsets)
+}
+
+func (p *pdfWriter) object(ref int, body string) {
+	p.offsets[ref-1] = p.n
+	p.write([]byte(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", ref, body)))
+}
+
+func (p *pdfWriter) stream(dict []string, data []byte) int {
+	ref := p.reserve()
+	p.offsets[ref-1] = p.n
+	p.write([]byte(fmt.Sprintf("%d 0 obj\n<< %s /Length %d >>\nstream\n", ref, strings.Join(dict, " "), len(data))))
+	p.write(data)
+	p.write([]byte("\nendstream\nendobj\n"))
+	return ref
+}
+
+// image embeds r of img as an image XObject, flattened onto white.
+func (p *pdfWriter) image(img image.Image, r image.Rectangle, opts PDFOptions) (int, error) {
+	gray := formatOf(img).gray()
+	var dst draw.Image
+	if gray {
+		dst = image.NewGray(image.Rect(0, 0, r.Dx(), r.Dy()))
+	} else {
+		dst = image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	}
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Over)
+
+	space := "/DeviceRGB"
+	if gray {
+		space = "/DeviceGray"
+	}
+	dict := []string{"/Type /XObject /Subtype /Image",
+		fmt.Sprintf("/Width %d /Height %d /ColorSpace %s /BitsPerComponent 8", r.Dx(), r.Dy(), space)}
+
+	var buf bytes.Buffer
+	if opts.JPEG {
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = jpeg.DefaultQuality
+		}
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: min(quality, 100)}); err != nil {
+			return 0, err
+		}
+		return p.stream(append(dict, "/Filter /DCTDecode"), buf.Bytes()), nil
+	}
+
+	z := zlib.NewWriter(&buf)
+	if g, ok := dst.(*image.Gray); ok {
+		z.Write(g.Pix)
+	} else {
+		rgba := dst.(*image.RGBA)
+		row := make([]byte, r.Dx()*3)
+		for y := 0; y < r.Dy(); y++ {
+			src := rgba.Pix[y*rgba.Stride:]
+			for x := range r.Dx() {
+				copy(row[x*3:x*3+3], src[x*4:x*4+3])
+			}
+			z.Write(row)
+		}
+	}
+	if err := z.Close(); err != nil {
+		return 0, err
+	}
+	return p.stream(append(dict, "/Filter /FlateDecode"), buf.Bytes()), nil
+}
+
+func (p *pdfWriter) close(root, info int) error {
+	xref := p.n
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(p.offsets)+1))
+	for _, off := range p.offsets {
+		b.WriteString(fmt.Sprintf("%010d 00000 n \n", off))
+	}
+	b.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(p.offsets)+1, root, info, xref))
+	p.write([]byte(b.String()))
+	return p.err
+}
+
+// pdfString escapes s for a literal string in WinAnsiEncoding, replacing
+// characters outside Latin-1 with '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		case r < 0x80:
+			b.WriteRune(r)
+		default:
+			b.WriteString(fmt.Sprintf("\\%03o", r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package data
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPageBreaks(t *testing.T) {
+	tests := []struct {
+		name       string
+		rects      []image.Rectangle
+		height     int
+		pageHeight int
+		want       [][2]int
+	}{
+		{
+			name:       "single page",
+			rects:      []image.Rectangle{image.Rect(0, 0, 10, 500)},
+			height:     500,
+			pageHeight: 1000,
+			want:       [][2]int{{0, 500}},
+		},
+		{
+			name:       "no boundaries",
+			rects:      []image.Rectangle{image.Rect(0, 0, 10, 2500)},
+			height:     2500,
+			pageHeight: 1000,
+			want:       [][2]int{{0, 1000}, {1000, 2000}, {2000, 2500}},
+		},
+		{
+			name: "breaks at image boundaries",
+			rects: []image.Rectangle{
+				image.Rect(0, 0, 10, 800),
+				image.Rect(0, 800, 10, 1500),
+				image.Rect(0, 1500, 10, 2000),
+			},
+			height:     2000,
+			pageHeight: 1000,
+			want:       [][2]int{{0, 800}, {800, 1500}, {1500, 2000}},
+		},
+		{
+			name: "boundary too early is ignored",
+			rects: []image.Rectangle{
+				image.Rect(0, 0, 10, 100),
+				image.Rect(0, 100, 10, 2000),
+			},
+			height:     2000,
+			pageHeight: 1000,
+			want:       [][2]int{{0, 1000}, {1000, 2000}},
+		},
+		{
+			name: "separators are skipped",
+			rects: []image.Rectangle{
+				image.Rect(0, 0, 10, 700),
+				image.Rect(0, 720, 10, 1400),
+				image.Rect(0, 1420, 10, 2000),
+			},
+			height:     2000,
+			pageHeight: 1000,
+			want:       [][2]int{{0, 700}, {720, 1400}, {1420, 2000}},
+		},
+		{
+			name: "mosaic with straddling cells",
+			rects: []image.Rectangle{
+				image.Rect(0, 0, 10, 1000),
+				image.Rect(10, 0, 20, 1300),
+				image.Rect(0, 1000, 10, 2000),
+				image.Rect(10, 1300, 20, 2000),
+			},
+			height:     2000,
+			pageHeight: 1200,
+			want:       [][2]int{{0, 1200}, {1200, 2000}},
+		},
+		{
+			name: "mosaic with aligned rows",
+			rects: []image.Rectangle{
+				image.Rect(10, 0, 20, 900),
+				image.Rect(0, 0, 10, 900),
+				image.Rect(0, 900, 10, 1800),
+				image.Rect(10, 900, 20, 1800),
+			},
+			height:     1800,
+			pageHeight: 1200,
+			want:       [][2]int{{0, 900}, {900, 1800}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan [][2]int, 1)
+			go func() { done <- pageBreaks(tt.rects, tt.height, tt.pageHeight) }()
+			select {
+			case got := <-done:
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("pageBreaks() = %v, want %v", got, tt.want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("pageBreaks() did not return")
+			}
+		})
+	}
+}
+
+func TestWritePDFUsesExport(t *testing.T) {
+	l := NewImageList()
+	l.Set([]*Image{NewImage(nil, image.NewRGBA(image.Rect(0, 0, 100, 4000)))})
+	stamp := DefaultStamp
+	stamp.Footer.Enabled = true
+	l.Stamp.Set(stamp)
+
+	var buf bytes.Buffer
+	if err := l.WritePDF(&buf, "test"); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if !bytes.HasPrefix(b, []byte("%PDF-")) || !bytes.HasSuffix(bytes.TrimSpace(b), []byte("%%EOF")) {
+		t.Fatal("output is not a PDF file")
+	}
+
+	// The page images cover the footer rows added by the stamp.
+	want := 4000 + stamp.Footer.height()
+	got := 0
+	for _, m := range regexp.MustCompile(`/Height (\d+)`).FindAllSubmatch(b, -1) {
+		h, _ := strconv.Atoi(string(m[1]))
+		got += h
+	}
+	if got != want {
+		t.Errorf("page images are %d rows high, want %d", got, want)
+	}
+}
+
+func TestWritePDFEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewImageList().WritePDF(&buf, "test"); !errors.Is(err, ErrEmptyList) {
+		t.Errorf("WritePDF() = %v, want %v", err, ErrEmptyList)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("WritePDF() wrote %d bytes", buf.Len())
+	}
+}
@@ -0,0 +1,262 @@
+package data
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// pngFilter is a PNG row filter type, or filterAdaptive to pick the filter
+// per row.
+type pngFilter int
+
+const (
+	filterNone pngFilter = iota
+	filterSub
+	filterUp
+	filterAverage
+	filterPaeth
+	filterAdaptive
+)
+
+// pngFilters are the filters the optimizer tries. Adaptive filtering does
+// well on photographic rows; no filtering at all often wins for palettes
+// and flat screenshots, where the heuristic misjudges.
+var pngFilters = []pngFilter{filterNone, filterAdaptive}
+
+// pngRaster is the unfiltered scanline data of an image in one of the
+// PNG color types the optimizer produces.
+type pngRaster struct {
+	width, height int
+	depth         uint8
+	colorType     uint8
+	bpp           int // bytes per complete pixel, at least 1
+	rows          [][]byte
+	palette       []byte
+	transparency  []byte
+}
+
+// newPNGRaster packs img the way png.Encoder would: grayscale and palettes
+// as they are, with palettes of up to 16 colors packed below 8 bits, and
+// everything else as RGB or RGBA at 8 or 16 bits depending on its alpha
+// and depth.
+func newPNGRaster(img image.Image) pngRaster {
+	b := img.Bounds()
+	p := pngRaster{width: b.Dx(), height: b.Dy(), depth: 8, bpp: 1}
+	switch src := img.(type) {
+	case *image.Gray:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := src.PixOffset(b.Min.X, y)
+			p.rows = append(p.rows, src.Pix[i:i+p.width])
+		}
+	case *image.Gray16:
+		p.depth, p.bpp = 16, 2
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := src.PixOffset(b.Min.X, y)
+			p.rows = append(p.rows, src.Pix[i:i+p.width*2])
+		}
+	case *image.Paletted:
+		p.colorType = 3
+		switch n := len(src.Palette); {
+		case n <= 2:
+			p.depth = 1
+		case n <= 4:
+			p.depth = 2
+		case n <= 16:
+			p.depth = 4
+		}
+		alpha := make([]byte, len(src.Palette))
+		for i, c := range src.Palette {
+			n := color.NRGBAModel.Convert(c).(color.NRGBA)
+			p.palette = append(p.palette, n.R, n.G, n.B)
+			if alpha[i] = n.A; n.A != 0xff {
+				p.transparency = alpha
+			}
+		}
+		perByte := 8 / int(p.depth)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := src.PixOffset(b.Min.X, y)
+			pix := src.Pix[i : i+p.width]
+			if perByte == 1 {
+				p.rows = append(p.rows, pix)
+				continue
+			}
+			row := make([]byte, (p.width+perByte-1)/perByte)
+			for x, v := range pix {
+				shift := 8 - int(p.depth)*(x%perByte+1)
+				row[x/perByte] |= v << shift
+			}
+			p.rows = append(p.rows, row)
+		}
+	default:
+		if formatOf(img).deep() {
+			rgba := toNRGBA64(img)
+			p.depth, p.colorType, p.bpp = 16, 6, 8
+			if opaque64(rgba) {
+				p.colorType, p.bpp = 2, 6
+			}
+			for y := range p.height {
+				pix := rgba.Pix[y*rgba.Stride:][:p.width*8]
+				p.rows = append(p.rows, packRGB(pix, p.bpp, 8))
+			}
+			break
+		}
+		rgba := toNRGBA(img)
+		p.colorType, p.bpp = 6, 4
+		if opaque(rgba) {
+			p.colorType, p.bpp = 2, 3
+		}
+		for y := range p.height {
+			pix := rgba.Pix[y*rgba.Stride:][:p.width*4]
+			p.rows = append(p.rows, packRGB(pix, p.bpp, 4))
+		}
+	}
+	return p
+}
+
+// packRGB drops the alpha channel of each pixel when bpp is smaller than
+// the stride of a pixel in pix.
+func packRGB(pix []byte, bpp, stride int) []byte {
+	if bpp == stride {
+		return pix
+	}
+	row := make([]byte, 0, len(pix)/stride*bpp)
+	for i := 0; i < len(pix); i += stride {
+		row = append(row, pix[i:i+bpp]...)
+	}
+	return row
+}
+
+func opaque(img *image.NRGBA) bool {
+	for y := range img.Bounds().Dy() {
+		pix := img.Pix[y*img.Stride:][:img.Bounds().Dx()*4]
+		for i := 3; i < len(pix); i += 4 {
+			if pix[i] != 0xff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func opaque64(img *image.NRGBA64) bool {
+	for y := range img.Bounds().Dy() {
+		pix := img.Pix[y*img.Stride:][:img.Bounds().Dx()*8]
+		for i := 6; i < len(pix); i += 8 {
+			if pix[i] != 0xff || pix[i+1] != 0xff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// encode writes the raster as a PNG, filtering every row with filter and
+// deflating at the zlib level matching the compression level.
+func (p pngRaster) encode(filter pngFilter, level png.CompressionLevel) ([]byte, error) {
+	var ihdr [13]byte
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(p.width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(p.height))
+	ihdr[8], ihdr[9] = p.depth, p.colorType
+
+	var idat bytes.Buffer
+	zl := zlib.DefaultCompression
+	if level == png.BestCompression {
+		zl = zlib.BestCompression
+	}
+	zw, err := zlib.NewWriterLevel(&idat, zl)
+	if err != nil {
+		return nil, err
+	}
+	var prev []byte
+	if len(p.rows) > 0 {
+		prev = make([]byte, len(p.rows[0]))
+	}
+	out := make([]byte, len(prev)+1)
+	best := make([]byte, len(prev)+1)
+	for _, row := range p.rows {
+		if filter == filterAdaptive {
+			score := -1
+			for f := filterNone; f < filterAdaptive; f++ {
+				applyFilter(out, row, prev, p.bpp, f)
+				if s := filterScore(out[1:]); score < 0 || s < score {
+					score = s
+					copy(best, out)
+				}
+			}
+		} else {
+			applyFilter(best, row, prev, p.bpp, filter)
+		}
+		if _, err := zw.Write(best); err != nil {
+			return nil, err
+		}
+		prev = row
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	writePNGChunk(&buf, "IHDR", ihdr[:])
+	if p.palette != nil {
+		writePNGChunk(&buf, "PLTE", p.palette)
+	}
+	if p.transparency != nil {
+		writePNGChunk(&buf, "tRNS", p.transparency)
+	}
+	writePNGChunk(&buf, "IDAT", idat.Bytes())
+	writePNGChunk(&buf, "IEND", nil)
+	return buf.Bytes(), nil
+}
+
+// applyFilter writes the filter type and the filtered row to dst.
+func applyFilter(dst, row, prev []byte, bpp int, f pngFilter) {
+	dst[0] = byte(f)
+	out := dst[1:]
+	for i, v := range row {
+		var a, b, c byte
+		if i >= bpp {
+			a, c = row[i-bpp], prev[i-bpp]
+		}
+		b = prev[i]
+		switch f {
+		case filterNone:
+			out[i] = v
+		case filterSub:
+			out[i] = v - a
+		case filterUp:
+			out[i] = v - b
+		case filterAverage:
+			out[i] = v - byte((int(a)+int(b))/2)
+		case filterPaeth:
+			out[i] = v - paeth(a, b, c)
+		}
+	}
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+// filterScore is the sum of the filtered bytes taken as signed values, the
+// heuristic the PNG specification suggests for choosing a filter per row.
+func filterScore(row []byte) int {
+	sum := 0
+	for _, v := range row {
+		sum += abs(int(int8(v)))
+	}
+	return sum
+}
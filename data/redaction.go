@@ -0,0 +1,159 @@
+package data
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/draw"
+)
+
+type RedactionStyle int
+
+const (
+	RedactBlur RedactionStyle = iota
+	RedactPixelate
+	RedactFill
+)
+
+var RedactionStyles = []RedactionStyle{RedactBlur, RedactPixelate, RedactFill}
+
+func (s RedactionStyle) String() string {
+	switch s {
+	case RedactBlur:
+		return "Blur"
+	case RedactPixelate:
+		return "Pixelate"
+	case RedactFill:
+		return "Black Box"
+	default:
+		return ""
+	}
+}
+
+const (
+	redactBlurRadius = 12
+	redactBlurPasses = 3
+	redactPixelBlock = 12
+)
+
+// Redaction is a region of an Image, in the image's own pixel coordinates,
+// whose pixels are replaced before the image is merged.
+type Redaction struct {
+	Rect  image.Rectangle
+	Style RedactionStyle
+}
+
+func (i *Image) Redacted() image.Image {
+	redactions, _ := i.Redactions.Get()
+	src := i.Source()
+	if len(redactions) == 0 {
+		return src
+	}
+	// Only the redacted regions are processed at 8 bits; the rest of the
+	// copy keeps the source's format.
+	b := src.Bounds()
+	dst := formatOf(src).New(b)
+	draw.Draw(dst, b, src, b.Min, draw.Src)
+	for _, v := range redactions {
+		r := v.Rect.Intersect(b)
+		if r.Empty() {
+			continue
+		}
+		region := image.NewRGBA(r)
+		draw.Draw(region, r, dst, r.Min, draw.Src)
+		v.Apply(region)
+		draw.Draw(dst, r, region, r.Min, draw.Src)
+	}
+	return dst
+}
+
+func (r *Redaction) Apply(dst *image.RGBA) {
+	rect := r.Rect.Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+	region := dst.SubImage(rect).(*image.RGBA)
+	switch r.Style {
+	case RedactBlur:
+		for range redactBlurPasses {
+			boxBlur(region, redactBlurRadius, true)
+			boxBlur(region, redactBlurRadius, false)
+		}
+	case RedactPixelate:
+		pixelate(region, redactPixelBlock)
+	case RedactFill:
+		draw.Draw(region, rect, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+}
+
+func (l ImageList) AddRedaction(merged image.Rectangle, style RedactionStyle) {
+	list, _ := l.Get()
+	for i, r := range l.Layout() {
+		c, _ := list[i].Crop.Get()
+		part := merged.Intersect(r)
+		if !part.Empty() {
+			list[i].Redactions.Append(&Redaction{Rect: part.Add(c.Min.Sub(r.Min)), Style: style})
+		}
+	}
+}
+
+func boxBlur(img *image.RGBA, radius int, horizontal bool) {
+	b := img.Bounds()
+	outer, inner := b.Dy(), b.Dx()
+	if !horizontal {
+		outer, inner = inner, outer
+	}
+	offset := func(o, i int) int {
+		if horizontal {
+			return img.PixOffset(b.Min.X+i, b.Min.Y+o)
+		}
+		return img.PixOffset(b.Min.X+o, b.Min.Y+i)
+	}
+
+	line := make([]uint8, inner*4)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			copy(line[i*4:i*4+4], img.Pix[offset(o, i):offset(o, i)+4])
+		}
+		var sum [4]int
+		for i := -radius; i <= radius; i++ {
+			p := min(max(i, 0), inner-1) * 4
+			for c := range sum {
+				sum[c] += int(line[p+c])
+			}
+		}
+		n := radius*2 + 1
+		for i := 0; i < inner; i++ {
+			p := offset(o, i)
+			for c := range sum {
+				img.Pix[p+c] = uint8(sum[c] / n)
+			}
+			add := min(i+radius+1, inner-1) * 4
+			sub := max(i-radius, 0) * 4
+			for c := range sum {
+				sum[c] += int(line[add+c]) - int(line[sub+c])
+			}
+		}
+	}
+}
+
+func pixelate(img *image.RGBA, block int) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y += block {
+		for x := b.Min.X; x < b.Max.X; x += block {
+			cell := image.Rect(x, y, x+block, y+block).Intersect(b)
+			var sum [4]int
+			for cy := cell.Min.Y; cy < cell.Max.Y; cy++ {
+				for cx := cell.Min.X; cx < cell.Max.X; cx++ {
+					p := img.PixOffset(cx, cy)
+					for c := range sum {
+						sum[c] += int(img.Pix[p+c])
+					}
+				}
+			}
+			n := cell.Dx() * cell.Dy()
+			avg := color.RGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), uint8(sum[3] / n)}
+			draw.Draw(img, cell, image.NewUniform(avg), image.Point{}, draw.Src)
+		}
+	}
+}
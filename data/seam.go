@@ -0,0 +1,233 @@
+package data
+
+import (
+	"image"
+	"image/color"
+	"slices"
+)
+
+// SeamQuality measures how well two consecutive items join. Edge is how
+// much more the rows either side of the cut differ than the rows next to
+// them do. Overlap is the mean difference between the rows trimmed off one
+// item and the kept rows of the other that they should duplicate, over
+// OverlapRows rows. Both are fractions of the full color range.
+type SeamQuality struct {
+	Measured    bool
+	Edge        float64
+	Overlap     float64
+	OverlapRows int
+}
+
+func (q SeamQuality) Score() float64 {
+	return max(q.Edge, q.Overlap)
+}
+
+type SeamGrade int
+
+const (
+	SeamGood SeamGrade = iota
+	SeamFair
+	SeamPoor
+)
+
+var SeamGrades = []SeamGrade{SeamGood, SeamFair, SeamPoor}
+
+func (g SeamGrade) String() string {
+	switch g {
+	case SeamGood:
+		return "Good"
+	case SeamFair:
+		return "Fair"
+	case SeamPoor:
+		return "Poor"
+	default:
+		return ""
+	}
+}
+
+// Grade rates the seam against threshold; a seam is fair from half the
+// threshold and poor from the threshold on.
+func (q SeamQuality) Grade(threshold float64) SeamGrade {
+	switch s := q.Score(); {
+	case s >= threshold:
+		return SeamPoor
+	case s >= threshold/2:
+		return SeamFair
+	default:
+		return SeamGood
+	}
+}
+
+// maxSeamRows limits how many overlap rows are compared on each side.
+const maxSeamRows = 64
+
+// seamRows pairs the rows of two consecutive items that a seam compares,
+// in the coordinates of their sources.
+type seamRows struct {
+	above, below image.Image
+	ax, bx       int
+	width        int
+
+	// edge is the last kept row of above and the first kept row of below.
+	edge [2]int
+
+	// overlap pairs the trimmed rows of each item with the kept rows of
+	// the other they should match.
+	overlap [][2]int
+}
+
+func newSeamRows(above, below *Image) (seamRows, bool) {
+	if above.Generated != nil || below.Generated != nil {
+		return seamRows{}, false
+	}
+	ra, _ := above.Crop.Get()
+	rb, _ := below.Crop.Get()
+	if ra.Empty() || rb.Empty() {
+		return seamRows{}, false
+	}
+	s := seamRows{
+		above: above.Source(),
+		below: below.Source(),
+		ax:    ra.Min.X,
+		bx:    rb.Min.X,
+		width: min(ra.Dx(), rb.Dx()),
+		edge:  [2]int{ra.Max.Y - 1, rb.Min.Y},
+	}
+
+	n := min(above.Bounds().Max.Y-ra.Max.Y, rb.Dy(), maxSeamRows)
+	for k := range n {
+		s.overlap = append(s.overlap, [2]int{ra.Max.Y + k, rb.Min.Y + k})
+	}
+	m := min(rb.Min.Y-below.Bounds().Min.Y, ra.Dy(), maxSeamRows)
+	for k := range m {
+		s.overlap = append(s.overlap, [2]int{ra.Max.Y - m + k, rb.Min.Y - m + k})
+	}
+	return s, true
+}
+
+func pixelDiff(a, b color.Color) float64 {
+	r0, g0, b0, _ := a.RGBA()
+	r1, g1, b1, _ := b.RGBA()
+	return float64(absDiff(r0, r1)+absDiff(g0, g1)+absDiff(b0, b1)) / (3 * 0xffff)
+}
+
+func rowDiff(a image.Image, ax, ay int, b image.Image, bx, by, width int) float64 {
+	if width <= 0 {
+		return 0
+	}
+	sum := 0.0
+	for x := range width {
+		sum += pixelDiff(a.At(ax+x, ay), b.At(bx+x, by))
+	}
+	return sum / float64(width)
+}
+
+func (s seamRows) quality() SeamQuality {
+	q := SeamQuality{Measured: true, OverlapRows: len(s.overlap)}
+
+	ya, yb := s.edge[0], s.edge[1]
+	cross := rowDiff(s.above, s.ax, ya, s.below, s.bx, yb, s.width)
+	local := 0.0
+	if ya > s.above.Bounds().Min.Y {
+		local = max(local, rowDiff(s.above, s.ax, ya-1, s.above, s.ax, ya, s.width))
+	}
+	if yb+1 < s.below.Bounds().Max.Y {
+		local = max(local, rowDiff(s.below, s.bx, yb, s.below, s.bx, yb+1, s.width))
+	}
+	q.Edge = max(cross-local, 0)
+
+	for _, v := range s.overlap {
+		q.Overlap += rowDiff(s.above, s.ax, v[0], s.below, s.bx, v[1], s.width)
+	}
+	if len(s.overlap) > 0 {
+		q.Overlap /= float64(len(s.overlap))
+	}
+	return q
+}
+
+// MeasureSeam rates the join between above and below. Seams next to a
+// generated item are not measured.
+func MeasureSeam(above, below *Image) SeamQuality {
+	s, ok := newSeamRows(above, below)
+	if !ok {
+		return SeamQuality{}
+	}
+	return s.quality()
+}
+
+// SeamQualities rates every seam of the list, the one after item i at
+// index i. A mosaic has no seams, and seams with a visible separator are
+// meant to show a gap, so they are not measured.
+func (l ImageList) SeamQualities() []SeamQuality {
+	list, _ := l.Get()
+	if m, _ := l.Mosaic.Get(); m.active() || len(list) < 2 {
+		return nil
+	}
+	qualities := make([]SeamQuality, len(list)-1)
+	for i := range qualities {
+		if l.SeparatorAfter(list[i]).Height() > 0 {
+			continue
+		}
+		qualities[i] = MeasureSeam(list[i], list[i+1])
+	}
+	return qualities
+}
+
+// PoorSeams returns the indexes of the seams scoring at or above threshold.
+func (l ImageList) PoorSeams(threshold float64) []int {
+	var poor []int
+	for i, q := range l.SeamQualities() {
+		if q.Measured && q.Grade(threshold) == SeamPoor {
+			poor = append(poor, i)
+		}
+	}
+	return poor
+}
+
+// SeamHeatmap returns an overlay for img, in the coordinates of its source,
+// that colors every pixel its seams compare by how much it differs from
+// its counterpart: green where they match, through to red.
+func (l ImageList) SeamHeatmap(img *Image) *image.NRGBA {
+	dst := image.NewNRGBA(img.Bounds())
+	list, _ := l.Get()
+	i := slices.Index(list, img)
+	if i < 0 {
+		return dst
+	}
+	if m, _ := l.Mosaic.Get(); m.active() {
+		return dst
+	}
+
+	paint := func(s seamRows, above bool) {
+		rows := append([][2]int{s.edge}, s.overlap...)
+		for _, v := range rows {
+			for x := range s.width {
+				d := pixelDiff(s.above.At(s.ax+x, v[0]), s.below.At(s.bx+x, v[1]))
+				if above {
+					dst.SetNRGBA(s.ax+x, v[0], heatColor(d))
+				} else {
+					dst.SetNRGBA(s.bx+x, v[1], heatColor(d))
+				}
+			}
+		}
+	}
+	if i > 0 && l.SeparatorAfter(list[i-1]).Height() == 0 {
+		if s, ok := newSeamRows(list[i-1], img); ok {
+			paint(s, false)
+		}
+	}
+	if i+1 < len(list) && l.SeparatorAfter(img).Height() == 0 {
+		if s, ok := newSeamRows(img, list[i+1]); ok {
+			paint(s, true)
+		}
+	}
+	return dst
+}
+
+func heatColor(d float64) color.NRGBA {
+	if d == 0 {
+		return color.NRGBA{0x00, 0xc0, 0x40, 0x50}
+	}
+	t := min(d*4, 1)
+	return color.NRGBA{0xff, uint8(0xd0 * (1 - t)), 0x00, uint8(0x80 + 0x7f*t)}
+}
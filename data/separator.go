@@ -0,0 +1,133 @@
+package data
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/fixed"
+)
+
+type SeparatorStyle int
+
+const (
+	SeparatorNone SeparatorStyle = iota
+	SeparatorGap
+	SeparatorLine
+	SeparatorTorn
+)
+
+var SeparatorStyles = []SeparatorStyle{SeparatorNone, SeparatorGap, SeparatorLine, SeparatorTorn}
+
+func (s SeparatorStyle) String() string {
+	switch s {
+	case SeparatorNone:
+		return "None"
+	case SeparatorGap:
+		return "Gap"
+	case SeparatorLine:
+		return "Line"
+	case SeparatorTorn:
+		return "Torn Edge"
+	default:
+		return ""
+	}
+}
+
+// Separator is the seam drawn between two stitched images. Size is the
+// height of the seam, filled with Background, and Color is used for the
+// line or torn edge marker drawn across it.
+type Separator struct {
+	Style      SeparatorStyle
+	Size       int
+	Color      color.NRGBA
+	Background color.NRGBA
+}
+
+var DefaultSeparator = Separator{
+	Size:       24,
+	Color:      color.NRGBA{0x80, 0x80, 0x80, 0xff},
+	Background: color.NRGBA{0xff, 0xff, 0xff, 0xff},
+}
+
+const (
+	separatorLineWidth = 2
+	separatorTornWidth = 2
+	separatorTornTooth = 12
+	separatorTornDash  = 6
+)
+
+func (s Separator) Height() int {
+	if s.Style == SeparatorNone {
+		return 0
+	}
+	return max(s.Size, 1)
+}
+
+func (s Separator) Draw(dst draw.Image, r image.Rectangle) {
+	if s.Style == SeparatorNone || r.Empty() {
+		return
+	}
+	draw.Draw(dst, r, image.NewUniform(s.Background), image.Point{}, draw.Src)
+
+	w, h := r.Dx(), r.Dy()
+	layer := image.NewRGBA(image.Rect(0, 0, w, h))
+	scanner := rasterx.NewScannerGV(w, h, layer, layer.Bounds())
+	mid := float64(h) / 2
+	switch s.Style {
+	case SeparatorLine:
+		stroker := rasterx.NewStroker(w, h, scanner)
+		stroker.SetStroke(fixed.I(separatorLineWidth), fixed.I(4), rasterx.ButtCap, nil, nil, rasterx.Miter)
+		stroker.Start(rasterx.ToFixedP(0, mid))
+		stroker.Line(rasterx.ToFixedP(float64(w), mid))
+		stroker.Stop(false)
+		scanner.SetColor(s.Color)
+		stroker.Draw()
+	case SeparatorTorn:
+		dasher := rasterx.NewDasher(w, h, scanner)
+		dasher.SetStroke(fixed.I(separatorTornWidth), fixed.I(4), rasterx.ButtCap, nil, nil, rasterx.Round,
+			[]float64{separatorTornDash, separatorTornDash / 2}, 0)
+		amp := min(float64(separatorTornTooth)/2, mid-separatorTornWidth)
+		dasher.Start(rasterx.ToFixedP(0, mid))
+		for x, up := 0, true; x < w; x, up = x+separatorTornTooth, !up {
+			y := mid + amp
+			if up {
+				y = mid - amp
+			}
+			dasher.Line(rasterx.ToFixedP(float64(x+separatorTornTooth), y))
+		}
+		dasher.Stop(false)
+		scanner.SetColor(s.Color)
+		dasher.Draw()
+	}
+	draw.Draw(dst, r, layer, image.Point{}, draw.Over)
+}
+
+func (l ImageList) SeparatorAfter(i *Image) Separator {
+	if s, _ := i.Separator.Get(); s != nil {
+		return *s
+	}
+	s, _ := l.Separator.Get()
+	return s
+}
+
+// Layout returns the rectangle each image occupies in the merged image,
+// accounting for the separators between them.
+func (l ImageList) Layout() []image.Rectangle {
+	if m, _ := l.Mosaic.Get(); m.active() {
+		return l.mosaicLayout(m)
+	}
+	list, _ := l.Get()
+	rects := make([]image.Rectangle, len(list))
+	y := 0
+	for i, v := range list {
+		if i > 0 {
+			y += l.SeparatorAfter(list[i-1]).Height()
+		}
+		c, _ := v.Crop.Get()
+		rects[i] = image.Rect(0, y, c.Dx(), y+c.Dy())
+		y += c.Dy()
+	}
+	return rects
+}
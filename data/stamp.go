@@ -0,0 +1,248 @@
+package data
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+type StampPosition int
+
+const (
+	StampTopLeft StampPosition = iota
+	StampTop
+	StampTopRight
+	StampCenter
+	StampBottomLeft
+	StampBottom
+	StampBottomRight
+)
+
+var StampPositions = []StampPosition{
+	StampTopLeft, StampTop, StampTopRight, StampCenter, StampBottomLeft, StampBottom, StampBottomRight,
+}
+
+func (p StampPosition) String() string {
+	switch p {
+	case StampTopLeft:
+		return "Top Left"
+	case StampTop:
+		return "Top"
+	case StampTopRight:
+		return "Top Right"
+	case StampCenter:
+		return "Center"
+	case StampBottomLeft:
+		return "Bottom Left"
+	case StampBottom:
+		return "Bottom"
+	case StampBottomRight:
+		return "Bottom Right"
+	default:
+		return ""
+	}
+}
+
+type Watermark struct {
+	Enabled  bool
+	Text     string
+	ImageURI string
+	Position StampPosition
+	Color    color.NRGBA
+	Opacity  float64
+	Tile     bool
+	Size     float64
+}
+
+type Footer struct {
+	Enabled bool
+	Date    bool
+	AppName string
+	Text    string
+}
+
+// Stamp is the overlay applied to the rendered image when it is saved.
+type Stamp struct {
+	Watermark Watermark
+	Footer    Footer
+}
+
+var DefaultStamp = Stamp{
+	Watermark: Watermark{
+		Position: StampBottomRight,
+		Color:    color.NRGBA{0x80, 0x80, 0x80, 0xff},
+		Opacity:  0.5,
+		Size:     32,
+	},
+	Footer: Footer{Date: true},
+}
+
+const (
+	stampMargin     = 16
+	stampTileGap    = 96
+	footerTextSize  = 14
+	footerPadding   = 10
+	footerDateStyle = "2006-01-02"
+)
+
+var (
+	footerBackground = color.NRGBA{0xf2, 0xf2, 0xf2, 0xff}
+	footerForeground = color.NRGBA{0x40, 0x40, 0x40, 0xff}
+)
+
+func (s Stamp) Apply(img image.Image) image.Image {
+	if !s.Watermark.Enabled && !s.Footer.Enabled {
+		return img
+	}
+
+	b := img.Bounds()
+	footerHeight := 0
+	if s.Footer.Enabled {
+		footerHeight = s.Footer.height()
+	}
+	format := formatOf(img)
+	if s.Watermark.Enabled {
+		format = format.withAlpha()
+	}
+	dst := format.New(image.Rect(0, 0, b.Dx(), b.Dy()+footerHeight))
+	draw.Draw(dst, b.Sub(b.Min), img, b.Min, draw.Src)
+
+	if s.Watermark.Enabled {
+		s.Watermark.draw(subImage(dst, image.Rect(0, 0, b.Dx(), b.Dy())))
+	}
+	if s.Footer.Enabled {
+		s.Footer.draw(subImage(dst, image.Rect(0, b.Dy(), b.Dx(), b.Dy()+footerHeight)))
+	}
+	return dst
+}
+
+func (w Watermark) mark() image.Image {
+	if w.ImageURI != "" {
+		if uri, err := storage.ParseURI(w.ImageURI); err == nil {
+			if r, err := storage.Reader(uri); err == nil {
+				defer r.Close()
+				if img, _, err := image.Decode(r); err == nil {
+					return img
+				}
+			}
+		}
+	}
+	if w.Text == "" || w.Size <= 0 {
+		return nil
+	}
+
+	face := textFace(w.Size)
+	defer face.Close()
+	tw, th := measureText(w.Text, w.Size)
+	mark := image.NewRGBA(image.Rect(0, 0, tw, th))
+	d := font.Drawer{Dst: mark, Src: image.NewUniform(w.Color), Face: face}
+	for i, line := range splitLines(w.Text) {
+		d.Dot = fixed.Point26_6{Y: face.Metrics().Ascent + face.Metrics().Height*fixed.Int26_6(i)}
+		d.DrawString(line)
+	}
+	return mark
+}
+
+func (w Watermark) draw(dst draw.Image) {
+	mark := w.mark()
+	if mark == nil {
+		return
+	}
+	mb, b := mark.Bounds(), dst.Bounds()
+	mask := image.NewUniform(color.Alpha{uint8(max(0, min(w.Opacity, 1)) * 0xff)})
+
+	if w.Tile {
+		for y := b.Min.Y; y < b.Max.Y; y += mb.Dy() + stampTileGap {
+			shift := ((y - b.Min.Y) / (mb.Dy() + stampTileGap) % 2) * (mb.Dx() + stampTileGap) / 2
+			for x := b.Min.X - shift; x < b.Max.X; x += mb.Dx() + stampTileGap {
+				r := image.Rectangle{image.Pt(x, y), image.Pt(x, y).Add(mb.Size())}
+				draw.DrawMask(dst, r, mark, mb.Min, mask, image.Point{}, draw.Over)
+			}
+		}
+		return
+	}
+
+	var p image.Point
+	switch w.Position {
+	case StampTopLeft, StampBottomLeft:
+		p.X = b.Min.X + stampMargin
+	case StampTop, StampCenter, StampBottom:
+		p.X = b.Min.X + (b.Dx()-mb.Dx())/2
+	default:
+		p.X = b.Max.X - mb.Dx() - stampMargin
+	}
+	switch w.Position {
+	case StampTopLeft, StampTop, StampTopRight:
+		p.Y = b.Min.Y + stampMargin
+	case StampCenter:
+		p.Y = b.Min.Y + (b.Dy()-mb.Dy())/2
+	default:
+		p.Y = b.Max.Y - mb.Dy() - stampMargin
+	}
+	draw.DrawMask(dst, image.Rectangle{p, p.Add(mb.Size())}, mark, mb.Min, mask, image.Point{}, draw.Over)
+}
+
+func (f Footer) height() int {
+	_, h := measureText("Ag", footerTextSize)
+	return h + footerPadding*2
+}
+
+func (f Footer) left() string {
+	return strings.Join(slices.DeleteFunc([]string{f.AppName, f.Text}, func(s string) bool { return s == "" }), " · ")
+}
+
+func (f Footer) draw(dst draw.Image) {
+	b := dst.Bounds()
+	draw.Draw(dst, b, image.NewUniform(footerBackground), image.Point{}, draw.Src)
+
+	face := textFace(footerTextSize)
+	defer face.Close()
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(footerForeground), Face: face}
+	y := fixed.I(b.Min.Y+footerPadding) + face.Metrics().Ascent
+
+	d.Dot = fixed.Point26_6{X: fixed.I(b.Min.X + footerPadding), Y: y}
+	d.DrawString(f.left())
+	if f.Date {
+		date := time.Now().Format(footerDateStyle)
+		d.Dot = fixed.Point26_6{X: fixed.I(b.Max.X-footerPadding) - d.MeasureString(date), Y: y}
+		d.DrawString(date)
+	}
+}
+
+const prefStampPresets = "stampPresets"
+
+func LoadStampPresets(p fyne.Preferences) map[string]Stamp {
+	presets := map[string]Stamp{}
+	json.Unmarshal([]byte(p.String(prefStampPresets)), &presets)
+	return presets
+}
+
+func SaveStampPreset(p fyne.Preferences, name string, stamp Stamp) error {
+	presets := LoadStampPresets(p)
+	presets[name] = stamp
+	return storeStampPresets(p, presets)
+}
+
+func DeleteStampPreset(p fyne.Preferences, name string) error {
+	presets := LoadStampPresets(p)
+	delete(presets, name)
+	return storeStampPresets(p, presets)
+}
+
+func storeStampPresets(p fyne.Preferences, presets map[string]Stamp) error {
+	b, err := json.Marshal(presets)
+	if err != nil {
+		return err
+	}
+	p.SetString(prefStampPresets, string(b))
+	return nil
+}
@@ -0,0 +1,283 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	"golang.org/x/image/draw"
+)
+
+// TileOptions controls tile pyramid export. Level 0 holds the exported image
+// at full size in Size×Size tiles, and every further level halves it until
+// it fits in a single tile.
+type TileOptions struct {
+	Size int
+	JPEG bool
+}
+
+var DefaultTileOptions = TileOptions{Size: 256}
+
+func (o TileOptions) ext() string {
+	if o.JPEG {
+		return ".jpg"
+	}
+	return ".png"
+}
+
+// WriteTiles writes a tile pyramid of the exported image under dir/tiles
+// and an index.html viewer for it. When the export is just the merged
+// image, it is rendered one row of tiles at a time, so it is never held in
+// memory as a whole.
+func (l ImageList) WriteTiles(dir fyne.URI, opts TileOptions, title string) error {
+	m := l.merger()
+	if m.size.X == 0 || m.size.Y == 0 {
+		return nil
+	}
+	size := m.size
+	var exported draw.Image
+	if !l.exportsMerge(size) {
+		exported = toRGBA(l.Export())
+		size = exported.Bounds().Size()
+	}
+	tile := max(opts.Size, 16)
+	levels := 1
+	for s := max(size.X, size.Y); s > tile; s = (s + 1) / 2 {
+		levels++
+	}
+
+	tiles, err := storage.Child(dir, "tiles")
+	if err != nil {
+		return err
+	}
+	if err := createDir(tiles); err != nil {
+		return err
+	}
+	t := &tileWriter{opts: opts, tile: tile, levels: levels, pending: make([]*image.RGBA, levels), rows: make([]int, levels)}
+	for z := range levels {
+		d, err := storage.Child(tiles, strconv.Itoa(z))
+		if err != nil {
+			return err
+		}
+		if err := createDir(d); err != nil {
+			return err
+		}
+		t.dirs = append(t.dirs, d)
+	}
+
+	annotations, _ := l.Annotations.Get()
+	for y := 0; y < size.Y; y += tile {
+		r := image.Rect(0, y, size.X, min(y+tile, size.Y))
+		var strip draw.Image
+		if exported != nil {
+			strip = subImage(exported, r)
+		} else {
+			strip = m.strip(r)
+			DrawAnnotations(strip, annotations, image.Point{}, 1)
+		}
+		if err := t.push(0, strip); err != nil {
+			return err
+		}
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+	return writeTileViewer(dir, size, tile, levels, opts.ext(), title)
+}
+
+func createDir(uri fyne.URI) error {
+	if ok, _ := storage.Exists(uri); ok {
+		return nil
+	}
+	return storage.CreateListable(uri)
+}
+
+type tileWriter struct {
+	opts    TileOptions
+	tile    int
+	levels  int
+	dirs    []fyne.URI
+	pending []*image.RGBA
+	rows    []int
+}
+
+// push writes one row of tiles at level z and feeds it to the next level,
+// which is halved once it has collected two rows.
+func (t *tileWriter) push(z int, strip draw.Image) error {
+	b := strip.Bounds()
+	for x := b.Min.X; x < b.Max.X; x += t.tile {
+		r := image.Rect(x, b.Min.Y, min(x+t.tile, b.Max.X), b.Max.Y)
+		name := fmt.Sprintf("%d_%d%s", t.rows[z], (x-b.Min.X)/t.tile, t.opts.ext())
+		if err := t.write(z, name, subImage(strip, r)); err != nil {
+			return err
+		}
+	}
+	t.rows[z]++
+	if z+1 == t.levels {
+		return nil
+	}
+
+	p := t.pending[z]
+	if p == nil {
+		t.pending[z] = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(t.pending[z], t.pending[z].Bounds(), strip, b.Min, draw.Src)
+		return nil
+	}
+	joined := image.NewRGBA(image.Rect(0, 0, p.Bounds().Dx(), p.Bounds().Dy()+b.Dy()))
+	draw.Draw(joined, p.Bounds(), p, image.Point{}, draw.Src)
+	draw.Draw(joined, image.Rect(0, p.Bounds().Dy(), b.Dx(), joined.Bounds().Dy()), strip, b.Min, draw.Src)
+	t.pending[z] = nil
+	return t.push(z+1, halve(joined))
+}
+
+func (t *tileWriter) flush() error {
+	for z, p := range t.pending {
+		if p != nil {
+			t.pending[z] = nil
+			if err := t.push(z+1, halve(p)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *tileWriter) write(z int, name string, img image.Image) error {
+	uri, err := storage.Child(t.dirs[z], name)
+	if err != nil {
+		return err
+	}
+	w, err := storage.Writer(uri)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return encode(w, t.opts.ext(), img)
+}
+
+func halve(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, (b.Dx()+1)/2, (b.Dy()+1)/2))
+	draw.BiLinear.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+func writeTileViewer(dir fyne.URI, size image.Point, tile, levels int, ext, title string) error {
+	config, err := json.Marshal(map[string]any{
+		"width": size.X, "height": size.Y, "tile": tile, "levels": levels, "ext": ext,
+	})
+	if err != nil {
+		return err
+	}
+	uri, err := storage.Child(dir, "index.html")
+	if err != nil {
+		return err
+	}
+	w, err := storage.Writer(uri)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	page := strings.NewReplacer("{{title}}", html.EscapeString(title), "{{config}}", string(config)).Replace(tileViewer)
+	_, err = w.Write([]byte(page))
+	return err
+}
+
+const tileViewer = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{title}}</title>
+<style>
+html, body { margin: 0; height: 100%; background: #333; font: 13px sans-serif; }
+#view { position: absolute; inset: 0; overflow: auto; }
+#canvas { position: relative; margin: 0 auto; background: #fff; }
+#canvas img { position: absolute; display: block; }
+#bar { position: fixed; top: 8px; right: 16px; z-index: 1; display: flex; gap: 4px; }
+#bar button, #bar span { padding: 4px 8px; border: 0; border-radius: 4px; background: rgba(0,0,0,.6); color: #fff; }
+</style>
+</head>
+<body>
+<div id="view"><div id="canvas"></div></div>
+<div id="bar"><button id="out">−</button><span id="zoom"></span><button id="in">+</button><button id="fit">Fit</button></div>
+<script>
+const config = {{config}};
+const view = document.getElementById("view");
+const canvas = document.getElementById("canvas");
+const sizes = [];
+for (let z = 0, w = config.width, h = config.height; z < config.levels; z++, w = Math.ceil(w / 2), h = Math.ceil(h / 2)) {
+  sizes.push([w, h]);
+}
+let scale = 1, level = -1, tiles = new Map();
+
+function fitScale() {
+  return Math.min(1, (view.clientWidth - 16) / config.width);
+}
+
+function setScale(s, cx, cy) {
+  cx = cx ?? view.clientWidth / 2;
+  cy = cy ?? view.clientHeight / 2;
+  const x = (view.scrollLeft + cx) / scale, y = (view.scrollTop + cy) / scale;
+  scale = Math.min(Math.max(s, 1 / 2 ** config.levels), 8);
+  canvas.style.width = config.width * scale + "px";
+  canvas.style.height = config.height * scale + "px";
+  view.scrollLeft = x * scale - cx;
+  view.scrollTop = y * scale - cy;
+  document.getElementById("zoom").textContent = Math.round(scale * 100) + "%";
+  update();
+}
+
+function update() {
+  const z = Math.min(Math.max(Math.floor(Math.log2(1 / scale)), 0), config.levels - 1);
+  if (z !== level) {
+    canvas.replaceChildren();
+    tiles.clear();
+    level = z;
+  }
+  const [w, h] = sizes[z];
+  const s = scale * config.width / w, t = config.tile * s;
+  const left = view.scrollLeft - canvas.offsetLeft, top = view.scrollTop;
+  const c0 = Math.max(Math.floor(left / t), 0), c1 = Math.min(Math.ceil((left + view.clientWidth) / t), Math.ceil(w / config.tile));
+  const r0 = Math.max(Math.floor(top / t), 0), r1 = Math.min(Math.ceil((top + view.clientHeight) / t), Math.ceil(h / config.tile));
+  for (let r = r0; r < r1; r++) {
+    for (let c = c0; c < c1; c++) {
+      if (tiles.has(r + "_" + c)) continue;
+      const img = document.createElement("img");
+      img.src = "tiles/" + z + "/" + r + "_" + c + config.ext;
+      img.dataset.row = r;
+      img.dataset.col = c;
+      tiles.set(r + "_" + c, img);
+      canvas.appendChild(img);
+    }
+  }
+  for (const img of tiles.values()) {
+    const r = +img.dataset.row, c = +img.dataset.col;
+    img.style.left = c * t + "px";
+    img.style.top = r * t + "px";
+    img.style.width = Math.min(config.tile, w - c * config.tile) * s + "px";
+    img.style.height = Math.min(config.tile, h - r * config.tile) * s + "px";
+  }
+}
+
+view.addEventListener("scroll", update);
+window.addEventListener("resize", update);
+view.addEventListener("wheel", e => {
+  if (!e.ctrlKey) return;
+  e.preventDefault();
+  const rect = view.getBoundingClientRect();
+  setScale(scale * (e.deltaY < 0 ? 1.25 : 0.8), e.clientX - rect.left, e.clientY - rect.top);
+}, { passive: false });
+document.getElementById("in").onclick = () => setScale(scale * 1.25);
+document.getElementById("out").onclick = () => setScale(scale * 0.8);
+document.getElementById("fit").onclick = () => setScale(fitScale());
+setScale(fitScale(), 0, 0);
+</script>
+</body>
+</html>
+`
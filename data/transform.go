@@ -0,0 +1,252 @@
+package data
+
+import (
+	"image"
+	"math"
+	"sync"
+
+	"golang.org/x/image/draw"
+)
+
+// Transform is a non-destructive change to an image's geometry. The image
+// is flipped first, then rotated clockwise by Rotate quarter turns, then
+// resized by Scale. A zero Scale means no resizing.
+type Transform struct {
+	Rotate       int
+	FlipH, FlipV bool
+	Scale        float64
+}
+
+func (t Transform) IsIdentity() bool {
+	return t.Rotate%4 == 0 && !t.FlipH && !t.FlipV && (t.Scale == 0 || t.Scale == 1)
+}
+
+func (t Transform) RotateBy(quarters int) Transform {
+	t.Rotate = ((t.Rotate+quarters)%4 + 4) % 4
+	return t
+}
+
+// Flip mirrors the transformed image horizontally or vertically, which for
+// a rotated image is the other axis of the source.
+func (t Transform) Flip(horizontal bool) Transform {
+	if (t.Rotate%2 == 1) == horizontal {
+		t.FlipV = !t.FlipV
+	} else {
+		t.FlipH = !t.FlipH
+	}
+	return t
+}
+
+func (t Transform) scale() float64 {
+	if t.Scale <= 0 {
+		return 1
+	}
+	return t.Scale
+}
+
+func (t Transform) Size(b image.Rectangle) image.Point {
+	w, h := b.Dx(), b.Dy()
+	if t.Rotate%2 == 1 {
+		w, h = h, w
+	}
+	s := t.scale()
+	return image.Pt(max(int(math.Round(float64(w)*s)), 1), max(int(math.Round(float64(h)*s)), 1))
+}
+
+func (t Transform) Apply(img image.Image) image.Image {
+	if t.IsIdentity() {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	rw, rh := w, h
+	if t.Rotate%2 == 1 {
+		rw, rh = h, w
+	}
+
+	format := formatOf(img)
+	in, ok := pixels(img)
+	if !ok {
+		conv := format.New(image.Rect(0, 0, w, h))
+		draw.Draw(conv, conv.Bounds(), img, b.Min, draw.Src)
+		in, _ = pixels(conv)
+	}
+	rotated := format.New(image.Rect(0, 0, rw, rh))
+	out, _ := pixels(rotated)
+	for y := range h {
+		row := in.pix[y*in.stride:]
+		for x := range w {
+			px, py := t.mapPixel(x, y, w, h)
+			copy(out.pix[py*out.stride+px*out.bpp:][:out.bpp], row[x*in.bpp:][:in.bpp])
+		}
+	}
+	if t.scale() == 1 {
+		return rotated
+	}
+	dst := format.New(image.Rectangle{Max: t.Size(b)})
+	draw.CatmullRom.Scale(dst, dst.Bounds(), rotated, rotated.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// pixelBuffer is the pixel data of an image, starting at its top left
+// corner.
+type pixelBuffer struct {
+	pix         []byte
+	stride, bpp int
+}
+
+// pixels returns the pixel data of the image types pixelFormat creates.
+func pixels(img image.Image) (pixelBuffer, bool) {
+	b := img.Bounds()
+	switch p := img.(type) {
+	case *image.RGBA:
+		return pixelBuffer{p.Pix[p.PixOffset(b.Min.X, b.Min.Y):], p.Stride, 4}, true
+	case *image.NRGBA:
+		return pixelBuffer{p.Pix[p.PixOffset(b.Min.X, b.Min.Y):], p.Stride, 4}, true
+	case *image.RGBA64:
+		return pixelBuffer{p.Pix[p.PixOffset(b.Min.X, b.Min.Y):], p.Stride, 8}, true
+	case *image.NRGBA64:
+		return pixelBuffer{p.Pix[p.PixOffset(b.Min.X, b.Min.Y):], p.Stride, 8}, true
+	case *image.Gray:
+		return pixelBuffer{p.Pix[p.PixOffset(b.Min.X, b.Min.Y):], p.Stride, 1}, true
+	case *image.Gray16:
+		return pixelBuffer{p.Pix[p.PixOffset(b.Min.X, b.Min.Y):], p.Stride, 2}, true
+	default:
+		return pixelBuffer{}, false
+	}
+}
+
+func (t Transform) mapPixel(x, y, w, h int) (int, int) {
+	if t.FlipH {
+		x = w - 1 - x
+	}
+	if t.FlipV {
+		y = h - 1 - y
+	}
+	for range t.Rotate % 4 {
+		x, y, w, h = h-1-y, x, h, w
+	}
+	return x, y
+}
+
+func (t Transform) mapPoint(x, y, w, h float64) (float64, float64) {
+	if t.FlipH {
+		x = w - x
+	}
+	if t.FlipV {
+		y = h - y
+	}
+	for range t.Rotate % 4 {
+		x, y, w, h = h-y, x, h, w
+	}
+	s := t.scale()
+	return x * s, y * s
+}
+
+func (t Transform) unmapPoint(x, y, w, h float64) (float64, float64) {
+	s := t.scale()
+	x, y = x/s, y/s
+	rw, rh := w, h
+	if t.Rotate%2 == 1 {
+		rw, rh = h, w
+	}
+	for range t.Rotate % 4 {
+		x, y, rw, rh = y, rw-x, rh, rw
+	}
+	if t.FlipH {
+		x = w - x
+	}
+	if t.FlipV {
+		y = h - y
+	}
+	return x, y
+}
+
+// MapRect maps r from the coordinates of src to the coordinates of the
+// transformed image.
+func (t Transform) MapRect(r, src image.Rectangle) image.Rectangle {
+	if t.IsIdentity() {
+		return r
+	}
+	w, h := float64(src.Dx()), float64(src.Dy())
+	x0, y0 := t.mapPoint(float64(r.Min.X-src.Min.X), float64(r.Min.Y-src.Min.Y), w, h)
+	x1, y1 := t.mapPoint(float64(r.Max.X-src.Min.X), float64(r.Max.Y-src.Min.Y), w, h)
+	return roundRect(x0, y0, x1, y1)
+}
+
+// UnmapRect is the inverse of MapRect.
+func (t Transform) UnmapRect(r, src image.Rectangle) image.Rectangle {
+	if t.IsIdentity() {
+		return r
+	}
+	w, h := float64(src.Dx()), float64(src.Dy())
+	x0, y0 := t.unmapPoint(float64(r.Min.X), float64(r.Min.Y), w, h)
+	x1, y1 := t.unmapPoint(float64(r.Max.X), float64(r.Max.Y), w, h)
+	return roundRect(x0, y0, x1, y1).Add(src.Min)
+}
+
+func roundRect(x0, y0, x1, y1 float64) image.Rectangle {
+	return image.Rect(
+		int(math.Round(x0)), int(math.Round(y0)),
+		int(math.Round(x1)), int(math.Round(y1)),
+	)
+}
+
+// transformed caches the transformed image. Source is called from
+// background work such as sorting and seam scoring, so it is locked.
+type transformed struct {
+	mu        sync.Mutex
+	transform Transform
+	image     image.Image
+}
+
+// Source returns the image with its transform applied. Crops and
+// redactions are in the coordinates of this image.
+func (i *Image) Source() image.Image {
+	t, _ := i.Transform.Get()
+	if t.IsIdentity() {
+		return i.Image
+	}
+	i.transformed.mu.Lock()
+	defer i.transformed.mu.Unlock()
+	if i.transformed.image == nil || i.transformed.transform != t {
+		i.transformed.transform, i.transformed.image = t, t.Apply(i.Image)
+	}
+	return i.transformed.image
+}
+
+func (i *Image) Bounds() image.Rectangle {
+	t, _ := i.Transform.Get()
+	if t.IsIdentity() {
+		return i.Image.Bounds()
+	}
+	return image.Rectangle{Max: t.Size(i.Image.Bounds())}
+}
+
+// SetTransform changes the transform and carries the crop and redactions
+// over to the new coordinates so they cover the same content.
+func (i *Image) SetTransform(t Transform) error {
+	old, _ := i.Transform.Get()
+	if t == old {
+		return nil
+	}
+	src := i.Image.Bounds()
+	remap := func(r image.Rectangle) image.Rectangle {
+		return t.MapRect(old.UnmapRect(r, src), src).Canon()
+	}
+
+	c, _ := i.Crop.Get()
+	c = remap(c)
+	redactions, _ := i.Redactions.Get()
+	for _, v := range redactions {
+		v.Rect = remap(v.Rect)
+	}
+	if err := i.Transform.Set(t); err != nil {
+		return err
+	}
+	i.phash.mu.Lock()
+	i.phash.hash = nil
+	i.phash.mu.Unlock()
+	i.Crop.Set(c)
+	return i.Redactions.Set(redactions)
+}
@@ -0,0 +1,81 @@
+package data
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func allTransforms() []Transform {
+	var transforms []Transform
+	for rotate := range 4 {
+		for _, flipH := range []bool{false, true} {
+			for _, flipV := range []bool{false, true} {
+				for _, scale := range []float64{0, 2} {
+					transforms = append(transforms, Transform{rotate, flipH, flipV, scale})
+				}
+			}
+		}
+	}
+	return transforms
+}
+
+func TestTransformRectRoundTrip(t *testing.T) {
+	src := image.Rect(10, 20, 70, 110)
+	rects := []image.Rectangle{
+		src,
+		image.Rect(10, 20, 11, 21),
+		image.Rect(15, 30, 40, 100),
+		image.Rect(69, 109, 70, 110),
+	}
+	for _, tr := range allTransforms() {
+		// The identity leaves the image, and so its coordinates, as is.
+		bounds := image.Rectangle{Max: tr.Size(src)}
+		if tr.IsIdentity() {
+			bounds = src
+		}
+		for _, r := range rects {
+			mapped := tr.MapRect(r, src).Canon()
+			if !mapped.In(bounds) {
+				t.Errorf("%+v: MapRect(%v) = %v, outside %v", tr, r, mapped, bounds)
+			}
+			if got := tr.UnmapRect(mapped, src).Canon(); got != r {
+				t.Errorf("%+v: UnmapRect(MapRect(%v)) = %v", tr, r, got)
+			}
+		}
+	}
+}
+
+func TestTransformApply(t *testing.T) {
+	formats := []pixelFormat{formatRGBA, formatNRGBA, formatRGBA64, formatNRGBA64, formatGray, formatGray16}
+	b := image.Rect(3, 4, 8, 11)
+	for _, f := range formats {
+		src := f.New(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				v := uint16((y-b.Min.Y)*b.Dx()+x-b.Min.X) * 0x0707
+				src.Set(x, y, color.RGBA64{v, v, v, 0xffff})
+			}
+		}
+		for _, tr := range allTransforms() {
+			tr.Scale = 0
+			name := fmt.Sprintf("%v %+v", f, tr)
+			dst := tr.Apply(src)
+			if formatOf(dst) != f {
+				t.Errorf("%s: format %v", name, formatOf(dst))
+			}
+			if dst.Bounds().Size() != tr.Size(b) {
+				t.Fatalf("%s: size %v, want %v", name, dst.Bounds().Size(), tr.Size(b))
+			}
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					p := tr.MapRect(image.Rect(x, y, x+1, y+1), b).Canon().Min
+					if got, want := color.RGBA64Model.Convert(dst.At(p.X, p.Y)), color.RGBA64Model.Convert(src.At(x, y)); got != want {
+						t.Fatalf("%s: pixel (%d, %d) at %v = %v, want %v", name, x, y, p, got, want)
+					}
+				}
+			}
+		}
+	}
+}
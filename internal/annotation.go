@@ -0,0 +1,385 @@
+package internal
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+type annotationTool int
+
+const (
+	annotationToolNone annotationTool = iota
+	annotationToolSelect
+	annotationToolDraw
+	annotationToolRedact
+)
+
+const textSizeFactor = 6
+
+type annotationState struct {
+	Tool   annotationTool
+	Kind   data.AnnotationKind
+	Redact data.RedactionStyle
+	Color  color.NRGBA
+	Size   float64
+
+	Selected    *data.Annotation
+	draft       *data.Annotation
+	draftRedact *data.Redaction
+}
+
+func newAnnotationState() *annotationState {
+	return &annotationState{Color: color.NRGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}, Size: 4}
+}
+
+func (e editor) newAnnotateMenu() *fyne.Menu {
+	s := e.annotation
+	var toolItems []*fyne.MenuItem
+	var toolChecks []func() bool
+	addTool := func(label string, checked func() bool, set func()) {
+		toolChecks = append(toolChecks, checked)
+		toolItems = append(toolItems, fyne.NewMenuItem(label, func() {
+			if checked() {
+				s.Tool = annotationToolNone
+			} else {
+				set()
+			}
+			if s.Tool != annotationToolSelect {
+				s.Selected = nil
+			}
+			for i, m := range toolItems {
+				m.Checked = toolChecks[i]()
+			}
+			e.MainMenu().Refresh()
+			e.list.refreshAnnotations()
+		}))
+	}
+
+	addTool("Select", func() bool { return s.Tool == annotationToolSelect }, func() {
+		s.Tool = annotationToolSelect
+	})
+	for _, k := range data.AnnotationKinds {
+		addTool(k.String(), func() bool { return s.Tool == annotationToolDraw && s.Kind == k }, func() {
+			s.Tool, s.Kind = annotationToolDraw, k
+		})
+	}
+	drawTools := len(toolItems)
+	for _, r := range data.RedactionStyles {
+		addTool("Redact: "+r.String(), func() bool { return s.Tool == annotationToolRedact && s.Redact == r }, func() {
+			s.Tool, s.Redact = annotationToolRedact, r
+		})
+	}
+
+	sizes := []struct {
+		label string
+		size  float64
+	}{{"Thin", 2}, {"Medium", 4}, {"Thick", 8}}
+	var sizeItems []*fyne.MenuItem
+	for _, v := range sizes {
+		sizeItems = append(sizeItems, &fyne.MenuItem{Label: v.label, Checked: s.Size == v.size})
+	}
+	for i, m := range sizeItems {
+		m.Action = func() {
+			s.Size = sizes[i].size
+			for j, o := range sizeItems {
+				o.Checked = i == j
+			}
+			e.MainMenu().Refresh()
+		}
+	}
+
+	items := append(slices.Clone(toolItems[:drawTools]), fyne.NewMenuItemSeparator())
+	items = append(items, toolItems[drawTools:]...)
+	items = append(items,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Color...", func() {
+			d := dialog.NewColorPicker("Annotation Color", "", func(c color.Color) {
+				s.Color = color.NRGBAModel.Convert(c).(color.NRGBA)
+			}, e)
+			d.Advanced = true
+			d.SetColor(s.Color)
+			d.Show()
+		}),
+		&fyne.MenuItem{Label: "Size", ChildMenu: fyne.NewMenu("", sizeItems...)},
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Delete Selected", func() {
+			if s.Selected != nil {
+				e.Images.Annotations.Remove(s.Selected)
+				s.Selected = nil
+			}
+		}),
+		e.newImageRequiredMenuItem("Clear Annotations", nil, func() {
+			s.Selected = nil
+			e.Images.Annotations.Set(nil)
+		}),
+	)
+	return fyne.NewMenu("Annotate", items...)
+}
+
+type annotationLayer struct {
+	widget.BaseWidget
+	Image *imageItem
+
+	raster   *canvas.Raster
+	listener binding.DataListener
+
+	moving   bool
+	start    image.Point
+	lastMove image.Point
+}
+
+func newAnnotationLayer(image *imageItem) *annotationLayer {
+	l := &annotationLayer{Image: image}
+	l.ExtendBaseWidget(l)
+	l.listener = binding.NewDataListener(l.Refresh)
+	image.List.Editor.Images.Annotations.AddListener(l.listener)
+	image.Data.Crop.AddListener(l.listener)
+	return l
+}
+
+func (l *annotationLayer) unbind() {
+	l.Image.List.Editor.Images.Annotations.RemoveListener(l.listener)
+	l.Image.Data.Crop.RemoveListener(l.listener)
+}
+
+func (l *annotationLayer) state() *annotationState {
+	return l.Image.List.Editor.annotation
+}
+
+func (l *annotationLayer) MinSize() fyne.Size {
+	return l.Image.pictureSize()
+}
+
+func (l *annotationLayer) scale() float64 {
+	return float64(l.Size().Width) / float64(l.Image.Data.Bounds().Dx())
+}
+
+func (l *annotationLayer) origin() image.Point {
+	b := l.Image.Data.Bounds()
+	c, _ := l.Image.Data.Crop.Get()
+	o := l.Image.List.Editor.Images.MergedOrigin(l.Image.Index)
+	return image.Pt(o.X+b.Min.X-c.Min.X, o.Y+b.Min.Y-c.Min.Y)
+}
+
+func (l *annotationLayer) toMerged(abs fyne.Position) image.Point {
+	pos := abs.Subtract(fyne.CurrentApp().Driver().AbsolutePositionForObject(l))
+	scale := l.scale()
+	o := l.origin()
+	return image.Pt(
+		int(math.Round(float64(pos.X)/scale))+o.X,
+		int(math.Round(float64(pos.Y)/scale))+o.Y,
+	)
+}
+
+func (l *annotationLayer) toImage(abs fyne.Position) image.Point {
+	pos := abs.Subtract(fyne.CurrentApp().Driver().AbsolutePositionForObject(l))
+	scale := l.scale()
+	return image.Pt(
+		int(math.Round(float64(pos.X)/scale)),
+		int(math.Round(float64(pos.Y)/scale)),
+	).Add(l.Image.Data.Bounds().Min)
+}
+
+func (l *annotationLayer) visible() []*data.Annotation {
+	annotations, _ := l.Image.List.Editor.Images.Annotations.Get()
+	if d := l.state().draft; d != nil {
+		annotations = append(annotations, d)
+	}
+	return annotations
+}
+
+func (l *annotationLayer) Tapped(e *fyne.PointEvent) {
+	s := l.state()
+	p := l.toMerged(e.AbsolutePosition)
+	annotations := l.Image.List.Editor.Images.Annotations
+
+	switch s.Tool {
+	case annotationToolNone:
+		l.Image.Tapped(e)
+	case annotationToolSelect:
+		list, _ := annotations.Get()
+		var hit *data.Annotation
+		for _, a := range slices.Backward(list) {
+			if p.In(a.Bounds()) {
+				hit = a
+				break
+			}
+		}
+		if hit != nil && hit == s.Selected && hit.Kind == data.AnnotationText {
+			l.showTextDialog(hit.Text, func(text string) {
+				hit.Text = text
+				list, _ := annotations.Get()
+				annotations.Set(list)
+			})
+		}
+		s.Selected = hit
+		l.Image.List.refreshAnnotations()
+	case annotationToolDraw:
+		if s.Kind != data.AnnotationText {
+			return
+		}
+		l.showTextDialog("", func(text string) {
+			annotations.Append(&data.Annotation{
+				Kind:   data.AnnotationText,
+				Points: []image.Point{p},
+				Color:  s.Color,
+				Size:   s.Size * textSizeFactor,
+				Text:   text,
+			})
+		})
+	}
+}
+
+func (l *annotationLayer) showTextDialog(text string, callback func(text string)) {
+	entry := widget.NewMultiLineEntry()
+	entry.SetText(text)
+	d := dialog.NewForm("Text", "OK", "Cancel", []*widget.FormItem{widget.NewFormItem("", entry)}, func(ok bool) {
+		if ok && entry.Text != "" {
+			callback(entry.Text)
+		}
+	}, l.Image.List.Editor)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
+
+func (l *annotationLayer) Dragged(e *fyne.DragEvent) {
+	s := l.state()
+	p := l.toMerged(e.AbsolutePosition)
+
+	switch s.Tool {
+	case annotationToolSelect:
+		if s.Selected == nil {
+			return
+		}
+		if !l.moving {
+			l.moving = true
+			l.lastMove = l.toMerged(e.AbsolutePosition.Subtract(e.Dragged))
+		}
+		s.Selected.Translate(p.Sub(l.lastMove))
+		l.lastMove = p
+		l.Image.List.refreshAnnotations()
+	case annotationToolRedact:
+		if s.draftRedact == nil {
+			l.start = l.toImage(e.AbsolutePosition.Subtract(e.Dragged))
+			s.draftRedact = &data.Redaction{Style: s.Redact}
+		}
+		s.draftRedact.Rect = image.Rectangle{l.start, l.toImage(e.AbsolutePosition)}.Canon()
+		l.Refresh()
+	case annotationToolDraw:
+		if s.Kind == data.AnnotationText {
+			return
+		}
+		if s.draft == nil {
+			l.start = l.toMerged(e.AbsolutePosition.Subtract(e.Dragged))
+			s.draft = &data.Annotation{Kind: s.Kind, Points: []image.Point{l.start}, Color: s.Color, Size: s.Size}
+		}
+		switch s.Kind {
+		case data.AnnotationMarker, data.AnnotationHighlighter:
+			s.draft.Points = append(s.draft.Points, p)
+		default:
+			s.draft.Points = []image.Point{l.start, p}
+		}
+		l.Image.List.refreshAnnotations()
+	}
+}
+
+func (l *annotationLayer) DragEnd() {
+	s := l.state()
+	if l.moving {
+		l.moving = false
+		list, _ := l.Image.List.Editor.Images.Annotations.Get()
+		l.Image.List.Editor.Images.Annotations.Set(list)
+	}
+	if s.draft != nil {
+		d := s.draft
+		s.draft = nil
+		l.Image.List.Editor.Images.Annotations.Append(d)
+	}
+	if s.draftRedact != nil {
+		r := s.draftRedact
+		s.draftRedact = nil
+		if !r.Rect.Empty() {
+			l.Image.Data.Redactions.Append(r)
+		}
+		l.Refresh()
+	}
+}
+
+func (l *annotationLayer) Cursor() desktop.Cursor {
+	switch l.state().Tool {
+	case annotationToolDraw:
+		if l.state().Kind == data.AnnotationText {
+			return desktop.TextCursor
+		}
+		return desktop.CrosshairCursor
+	case annotationToolRedact:
+		return desktop.CrosshairCursor
+	case annotationToolSelect:
+		return desktop.PointerCursor
+	default:
+		return desktop.DefaultCursor
+	}
+}
+
+func (l *annotationLayer) CreateRenderer() fyne.WidgetRenderer {
+	l.raster = canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		if w == 0 || h == 0 {
+			return img
+		}
+		scale := float64(w) / float64(l.Image.Data.Bounds().Dx())
+		b := l.Image.Data.Bounds()
+		c, _ := l.Image.Data.Crop.Get()
+		clip := image.Rect(
+			int(float64(c.Min.X-b.Min.X)*scale), int(float64(c.Min.Y-b.Min.Y)*scale),
+			int(math.Ceil(float64(c.Max.X-b.Min.X)*scale)), int(math.Ceil(float64(c.Max.Y-b.Min.Y)*scale)),
+		)
+		data.DrawAnnotations(img.SubImage(clip).(*image.RGBA), l.visible(), l.origin(), scale)
+
+		if sel := l.state().Selected; sel != nil && l.state().Tool == annotationToolSelect {
+			r := sel.Bounds().Sub(l.origin())
+			r = image.Rect(
+				int(float64(r.Min.X)*scale), int(float64(r.Min.Y)*scale),
+				int(float64(r.Max.X)*scale), int(float64(r.Max.Y)*scale),
+			)
+			drawDashedRect(img, r, l.Theme().Color(theme.ColorNamePrimary, fyne.CurrentApp().Settings().ThemeVariant()))
+		}
+		if d := l.state().draftRedact; d != nil && l.state().Tool == annotationToolRedact {
+			r := d.Rect.Sub(l.Image.Data.Bounds().Min)
+			r = image.Rect(
+				int(float64(r.Min.X)*scale), int(float64(r.Min.Y)*scale),
+				int(float64(r.Max.X)*scale), int(float64(r.Max.Y)*scale),
+			)
+			drawDashedRect(img, r, l.Theme().Color(theme.ColorNameError, fyne.CurrentApp().Settings().ThemeVariant()))
+		}
+		return img
+	})
+	return widget.NewSimpleRenderer(l.raster)
+}
+
+func drawDashedRect(img *image.RGBA, r image.Rectangle, clr color.Color) {
+	const dash = 4
+	for x := r.Min.X; x < r.Max.X; x++ {
+		if (x/dash)%2 == 0 {
+			img.Set(x, r.Min.Y, clr)
+			img.Set(x, r.Max.Y-1, clr)
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		if (y/dash)%2 == 0 {
+			img.Set(r.Min.X, y, clr)
+			img.Set(r.Max.X-1, y, clr)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package internal
 
 import (
+	"fmt"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
@@ -23,17 +25,29 @@ type editor struct {
 	Images data.ImageList
 
 	scroll *container.Scroll
+	list   *imageList
+
+	annotation *annotationState
 }
 
+// editors lists the open editor windows, in the order they were opened.
+var editors []*editor
+
 func ShowEditor(a fyne.App, images data.ImageList) {
-	e := &editor{Window: a.NewWindow("Rollshot"), Images: images}
+	e := &editor{Window: a.NewWindow("Rollshot"), Images: images, annotation: newAnnotationState()}
+	editors = append(editors, e)
+	e.SetOnClosed(func() { editors = slices.DeleteFunc(editors, func(v *editor) bool { return v == e }) })
+	if images.Length() == 0 {
+		images.ColorSpace.Set(data.ColorSpace(a.Preferences().Int(prefColorSpace)))
+	}
 	g := NewGlobalizer(nil)
 
 	innerPadding := theme.InnerPadding()
 
+	e.list = newImageList(e, g)
 	e.scroll = container.NewVScroll(container.New(
 		layout.NewCustomPaddedLayout(innerPadding, innerPadding, innerPadding, innerPadding),
-		container.NewBorder(nil, nil, newImageList(e, g), nil)),
+		container.NewBorder(nil, nil, e.list, nil)),
 	)
 
 	reverse := newDynamicButton("Reverse", theme.ViewRefreshIcon(), e.ReverseImages)
@@ -53,18 +67,15 @@ func ShowEditor(a fyne.App, images data.ImageList) {
 	}))
 
 	e.SetOnDropped(func(pos fyne.Position, items []fyne.URI) {
-		go func() {
-			for _, v := range items {
-				img, closed := e.tryLoadImage(v)
-				if img != nil {
-					images.Append(img)
-				}
-				if closed != nil {
-					<-closed
-					time.Sleep(time.Second / 60)
-				}
+		files := make([]fyne.URI, 0, len(items))
+		for _, v := range items {
+			if data.IsFolder(v) {
+				e.ShowFolderImportDialog(v, e.AddImages)
+			} else {
+				files = append(files, v)
 			}
-		}()
+		}
+		e.AddImages(files)
 	})
 
 	e.SetMainMenu(fyne.NewMainMenu(
@@ -75,23 +86,47 @@ func ShowEditor(a fyne.App, images data.ImageList) {
 			&fyne.MenuItem{Label: "Open...", Shortcut: ShortcutOpen{}, Action: func() {
 				e.ShowImageOpenDialog(func(img *data.Image) {
 					l := data.NewImageList()
+					space, _ := e.Images.ColorSpace.Get()
+					l.ColorSpace.Set(space)
 					l.Set([]*data.Image{img})
 					ShowEditor(a, l)
 				})
 			}},
 			fyne.NewMenuItemSeparator(),
 			&fyne.MenuItem{Label: "Add...", Action: e.ShowImageAddDialog},
+			&fyne.MenuItem{Label: "Add Folder...", Action: func() { e.ShowFolderOpenDialog(e.AddImages) }},
 			fyne.NewMenuItemSeparator(),
 			e.newImageRequiredMenuItem("Preview", nil, e.ShowImagePreviewDialog),
 			e.newImageRequiredMenuItem("Save As...", ShortcutSave{}, e.ShowImageSaveDialog),
+			e.newImageRequiredMenuItem("Export Tiles...", nil, e.ShowTileExportDialog),
+			e.newImageRequiredMenuItem("Export Carousel...", nil, e.ShowCarouselDialog),
+			e.newImageRequiredMenuItem("Compare With...", nil, e.ShowCompareDialog),
+			&fyne.MenuItem{Label: "Output Size...", Action: e.ShowOutputDialog},
+			&fyne.MenuItem{Label: "Watermark & Footer...", Action: e.ShowStampDialog},
 			fyne.NewMenuItemSeparator(),
 			&fyne.MenuItem{Label: "Close", Shortcut: ShortcutClose{}, Action: e.Close},
 		),
 		fyne.NewMenu("Edit",
+			e.newConditionalMenuItem("Undo", ShortcutUndo{}, images.CanUndo, func() { images.Undo() }),
+			e.newConditionalMenuItem("Redo", ShortcutRedo{}, images.CanRedo, func() { images.Redo() }),
+			fyne.NewMenuItemSeparator(),
+			e.newImageRequiredMenuItem("Select All", ShortcutSelectAll{}, func() {
+				v, _ := images.Get()
+				e.list.SetSelection(v)
+			}),
+			e.newImageRequiredMenuItem("Select None", nil, func() { e.list.SetSelection(nil) }),
+			fyne.NewMenuItemSeparator(),
+			e.newInsertMenuItem(),
+			fyne.NewMenuItemSeparator(),
 			e.newImageRequiredMenuItem("Reverse", nil, e.ReverseImages),
+			e.newSortMenuItem(),
+			e.newImageRequiredMenuItem("Find Duplicates...", nil, e.ShowDuplicatesDialog),
+			&fyne.MenuItem{Label: "Separators...", Action: e.ShowSeparatorDialog},
+			&fyne.MenuItem{Label: "Mosaic...", Action: e.ShowMosaicDialog},
 			fyne.NewMenuItemSeparator(),
-			e.newImageRequiredMenuItem("Clear", nil, func() { images.Set([]*data.Image{}) }),
+			e.newImageRequiredMenuItem("Clear", nil, func() { images.Clear() }),
 		),
+		e.newAnnotateMenu(),
 	))
 
 	g.Content = container.New(
@@ -103,26 +138,95 @@ func ShowEditor(a fyne.App, images data.ImageList) {
 }
 
 func (e editor) ReverseImages() {
-	v, _ := e.Images.Get()
-	slices.Reverse(v)
-	e.Images.Set(v)
+	e.Images.Reverse()
 	e.scroll.Content.Refresh()
 }
 
+func (e editor) ScrollToImage(img *data.Image) {
+	for _, obj := range e.list.container.Objects {
+		if item, ok := obj.(*imageItem); ok && item.Data == img {
+			d := fyne.CurrentApp().Driver()
+			y := d.AbsolutePositionForObject(item).Y - d.AbsolutePositionForObject(e.scroll.Content).Y
+			e.scroll.Offset = fyne.NewPos(0, y)
+			e.scroll.Refresh()
+			return
+		}
+	}
+}
+
+func (e editor) AddImages(uris []fyne.URI) {
+	var files []fyne.URI
+	var skipped []string
+	seen := map[string]bool{}
+	for _, v := range uris {
+		if seen[v.String()] || e.Images.ContainsURI(v) {
+			skipped = append(skipped, v.Name())
+			continue
+		}
+		seen[v.String()] = true
+		if data.IsAnimation(v) {
+			e.ShowFrameImportDialog(v, func(images []*data.Image) {
+				e.Images.Add(images...)
+			})
+			continue
+		}
+		files = append(files, v)
+	}
+	if len(skipped) > 0 {
+		dialog.ShowInformation("Duplicate Images", "Already in the list:\n"+strings.Join(skipped, "\n"), e)
+	}
+
+	go func() {
+		for _, v := range files {
+			img, closed := e.tryLoadImage(v)
+			if img != nil {
+				e.Images.Add(img)
+			}
+			if closed != nil {
+				<-closed
+				time.Sleep(time.Second / 60)
+			}
+		}
+	}()
+}
+
 func (e editor) ShowImageAddDialog() {
-	e.ShowImageOpenDialog(func(img *data.Image) { e.Images.Append(img) })
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, e)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		reader.Close()
+		e.AddImages([]fyne.URI{reader.URI()})
+	}, e)
+	d.SetFilter(storage.NewExtensionFileFilter(data.SupportedExtensions))
+	d.Show()
 }
 
 func (e editor) ShowImagePreviewDialog() {
-	img := canvas.NewImageFromImage(e.Images.Merge())
-	img.FillMode = canvas.ImageFillContain
-	img.ScaleMode = canvas.ImageScaleFastest
-	img.SetMinSize(imageSizeByBounds(img.Image.Bounds()))
-	d := dialog.NewCustom("Preview", "Close", img, e)
+	preview := newImagePreview(&e)
+	toolbar := container.NewHBox(widget.NewLabel("Redact"), e.newRedactSelect(preview))
+	decoration := container.NewVScroll(container.NewVBox(
+		e.newDecorationPanel(preview),
+		widget.NewSeparator(),
+		e.newColumnsPanel(preview),
+	))
+	d := dialog.NewCustom("Preview", "Close", container.NewBorder(toolbar, nil, nil, decoration, preview), e)
 	d.Show()
 }
 
 func (e editor) ShowImageSaveDialog() {
+	e.showSaveDialog(e.Images)
+}
+
+func (e editor) ShowSelectionSaveDialog() {
+	e.showSaveDialog(e.Images.Subset(e.list.Selected()))
+}
+
+func (e editor) showSaveDialog(images data.ImageList) {
 	d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
 		if err != nil {
 			dialog.ShowError(err, e)
@@ -131,22 +235,58 @@ func (e editor) ShowImageSaveDialog() {
 		if writer == nil {
 			return
 		}
-		defer writer.Close()
-		if err := e.Images.Save(writer); err != nil {
-			dialog.ShowError(err, e)
-			return
-		}
-		popUp := widget.NewPopUp(&widget.Label{
-			Text:      "Saved successfully",
-			Alignment: fyne.TextAlignCenter,
-			TextStyle: fyne.TextStyle{Bold: true},
-		}, e.Canvas())
-		cs, ps := e.Canvas().Size(), popUp.MinSize()
-		popUp.ShowAtPosition(fyne.NewPos(cs.Width/2, cs.Height/2).SubtractXY(ps.Width/2, ps.Height/2))
+		// Rendering and PNG optimization can take seconds on a long
+		// capture, so they run off the UI goroutine.
+		progress := dialog.NewCustomWithoutButtons("Saving", widget.NewProgressBarInfinite(), e)
+		progress.Show()
+		go func() {
+			defer writer.Close()
+			report, err := images.SaveWithReport(writer)
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			text := "Saved successfully"
+			if report.Original > 0 {
+				text += "\n" + report.String()
+			}
+			e.showSavedPopUp(text)
+		}()
 	}, e)
-	d.SetFilter(storage.NewMimeTypeFileFilter([]string{"image/jpeg", "image/png"}))
+	d.SetFilter(storage.NewMimeTypeFileFilter([]string{"image/jpeg", "image/png", "application/pdf"}))
 	d.SetFileName("image.png")
-	d.Show()
+
+	// Scoring every seam reads the full-resolution images, so it runs off
+	// the UI goroutine.
+	go func() {
+		poor := images.PoorSeams(seamThreshold())
+		if len(poor) == 0 {
+			d.Show()
+			return
+		}
+		seams := make([]string, len(poor))
+		for i, v := range poor {
+			seams[i] = strconv.Itoa(v + 1)
+		}
+		dialog.ShowConfirm("Seam Mismatch",
+			fmt.Sprintf("Seams exceeding the mismatch threshold follow image %s.\nSave anyway?", strings.Join(seams, ", ")),
+			func(ok bool) {
+				if ok {
+					d.Show()
+				}
+			}, e)
+	}()
+}
+
+func (e editor) showSavedPopUp(text string) {
+	popUp := widget.NewPopUp(&widget.Label{
+		Text:      text,
+		Alignment: fyne.TextAlignCenter,
+		TextStyle: fyne.TextStyle{Bold: true},
+	}, e.Canvas())
+	cs, ps := e.Canvas().Size(), popUp.MinSize()
+	popUp.ShowAtPosition(fyne.NewPos(cs.Width/2, cs.Height/2).SubtractXY(ps.Width/2, ps.Height/2))
 }
 
 func (e editor) ShowImageOpenDialog(callback func(img *data.Image)) {
@@ -168,7 +308,8 @@ func (e editor) ShowImageOpenDialog(callback func(img *data.Image)) {
 }
 
 func (e editor) tryLoadImage(uri fyne.URI) (image *data.Image, dialogClosed <-chan struct{}) {
-	if img, err := data.LoadImage(uri); err == nil {
+	space, _ := e.Images.ColorSpace.Get()
+	if img, err := data.LoadImage(uri, space); err == nil {
 		return img, nil
 	} else {
 		d := dialog.NewError(err, e)
@@ -180,15 +321,33 @@ func (e editor) tryLoadImage(uri fyne.URI) (image *data.Image, dialogClosed <-ch
 }
 
 func (e editor) newImageRequiredMenuItem(label string, shortcut fyne.Shortcut, action func()) *fyne.MenuItem {
+	return e.newConditionalMenuItem(label, shortcut, func() bool { return e.Images.Length() > 0 }, action)
+}
+
+func (e editor) newConditionalMenuItem(label string, shortcut fyne.Shortcut, enabled func() bool, action func()) *fyne.MenuItem {
 	m := &fyne.MenuItem{Label: label, Shortcut: shortcut}
 	m.Action = func() {
 		if !m.Disabled {
 			action()
 		}
 	}
+	e.Images.AddListener(binding.NewDataListener(func() {
+		m.Disabled = !enabled()
+	}))
+	return m
+}
+
+func (e editor) newSortMenuItem() *fyne.MenuItem {
+	m := &fyne.MenuItem{Label: "Sort By"}
 	e.Images.AddListener(binding.NewDataListener(func() {
 		m.Disabled = e.Images.Length() == 0
 	}))
+	var items []*fyne.MenuItem
+	for _, v := range data.SortOrders {
+		items = append(items, fyne.NewMenuItem(v.String(), func() { e.Images.Sort(v) }))
+	}
+	items = append(items, fyne.NewMenuItem("Content", func() { go e.Images.SortByContent() }))
+	m.ChildMenu = fyne.NewMenu("", items...)
 	return m
 }
 
@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+const carouselThumbHeight = 320
+
+func (e editor) ShowCarouselDialog() {
+	c := data.DefaultCarousel
+	merged := e.Images.Export()
+	var src image.Image
+	var cuts []int
+	var slides []image.Image
+
+	thumbs := container.NewHBox()
+	var rebuild func()
+	rebuild = func() {
+		slides = c.Slides(src, cuts)
+		thumbs.RemoveAll()
+		for i, slide := range slides {
+			img := canvas.NewImageFromImage(slide)
+			img.FillMode = canvas.ImageFillContain
+			img.SetMinSize(fyne.NewSize(carouselThumbHeight*float32(c.Width)/float32(c.Height), carouselThumbHeight))
+			box := container.NewVBox(img, widget.NewLabelWithStyle(fmt.Sprintf("%d / %d", i+1, len(slides)), fyne.TextAlignCenter, fyne.TextStyle{}))
+			if i > 0 {
+				cut := widget.NewSlider(float64(cuts[i-1]+1), float64(c.MaxCut(cuts, i)))
+				cut.Value = float64(cuts[i])
+				cut.OnChangeEnded = func(v float64) {
+					cuts = c.Cuts(src, append(slices.Clone(cuts[:i]), int(v)))
+					rebuild()
+				}
+				box.Add(cut)
+			}
+			thumbs.Add(box)
+		}
+		thumbs.Refresh()
+	}
+	reset := func() {
+		src = c.Scale(merged)
+		cuts = c.Cuts(src, nil)
+		rebuild()
+	}
+
+	sizes := make([]string, len(data.CarouselSizes))
+	for i, v := range data.CarouselSizes {
+		sizes[i] = fmt.Sprintf("%d × %d", v.X, v.Y)
+	}
+	size := widget.NewSelect(sizes, func(s string) {
+		v := data.CarouselSizes[slices.Index(sizes, s)]
+		c.Width, c.Height = v.X, v.Y
+		reset()
+	})
+	overlap := widget.NewEntry()
+	overlap.SetText(strconv.Itoa(c.Overlap))
+	overlap.OnChanged = func(s string) {
+		if v, err := strconv.Atoi(s); err == nil && v >= 0 && v < c.Height {
+			c.Overlap = v
+			reset()
+		}
+	}
+	indicator := widget.NewCheck("Page indicator", func(b bool) {
+		c.Indicator = b
+		rebuild()
+	})
+	indicator.Checked = c.Indicator
+	snap := widget.NewCheck("Snap cuts to blank rows", func(b bool) {
+		c.Snap = b
+		reset()
+	})
+	snap.Checked = c.Snap
+	size.SetSelected(sizes[0])
+
+	export := widget.NewButton("Export...", func() {
+		d := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			if dir == nil {
+				return
+			}
+			if err := data.SaveSlides(dir, "slide", slides); err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			e.showSavedPopUp(fmt.Sprintf("Exported %d slides", len(slides)))
+		}, e)
+		d.Show()
+	})
+	export.Importance = widget.HighImportance
+
+	toolbar := container.NewHBox(
+		widget.NewLabel("Size"), size,
+		widget.NewLabel("Overlap"), overlap,
+		indicator, snap,
+		widget.NewButton("Reset Cuts", reset),
+		export,
+	)
+	d := dialog.NewCustom("Carousel", "Close", container.NewBorder(toolbar, nil, nil, nil, container.NewHScroll(thumbs)), e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width*3, carouselThumbHeight*1.6))
+	d.Show()
+}
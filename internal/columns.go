@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+const maxColumns = 6
+
+func (e editor) newColumnsPanel(preview *imagePreview) fyne.CanvasObject {
+	c, _ := e.Images.Columns.Get()
+	update := func() {
+		e.Images.Columns.Set(c)
+		preview.Rearrange()
+	}
+
+	count := widget.NewSlider(1, maxColumns)
+	count.SetValue(float64(c.Count))
+	count.OnChangeEnded = func(f float64) {
+		c.Count = int(f)
+		update()
+	}
+	gap := widget.NewSlider(0, 256)
+	gap.SetValue(float64(c.Gap))
+	gap.OnChangeEnded = func(f float64) {
+		c.Gap = int(f)
+		update()
+	}
+	background := widget.NewButton("Color...", func() {
+		p := dialog.NewColorPicker("Column Background", "", func(v color.Color) {
+			c.Background = color.NRGBAModel.Convert(v).(color.NRGBA)
+			update()
+		}, e)
+		p.Advanced = true
+		p.SetColor(c.Background)
+		p.Show()
+	})
+
+	return widget.NewForm(
+		widget.NewFormItem("Columns", count),
+		widget.NewFormItem("Column Gap", gap),
+		widget.NewFormItem("Gap Color", background),
+		widget.NewFormItem("", widget.NewButton("Single Column", func() {
+			c = data.DefaultColumns
+			count.SetValue(float64(c.Count))
+			gap.SetValue(float64(c.Gap))
+			update()
+		})),
+	)
+}
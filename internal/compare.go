@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+// ShowCompareDialog puts this editor's list next to the list of another
+// open editor window.
+func (e editor) ShowCompareDialog() {
+	var others []*editor
+	var names []string
+	for i, v := range editors {
+		if v.Window == e.Window || v.Images.Length() == 0 {
+			continue
+		}
+		list, _ := v.Images.Get()
+		others = append(others, v)
+		names = append(names, fmt.Sprintf("Window %d: %s (%d images)", i+1, list[0].Name(), len(list)))
+	}
+	if len(others) == 0 {
+		dialog.ShowInformation("Compare", "Open the other capture in a new window to compare with it.", e)
+		return
+	}
+
+	c := data.DefaultComparison
+	var after data.ImageList
+	img := canvas.NewImageFromImage(nil)
+	img.FillMode = canvas.ImageFillContain
+	img.ScaleMode = canvas.ImageScaleFastest
+	update := func() {
+		if after.TypedList == nil {
+			return
+		}
+		img.Image = c.Render(e.Images, after)
+		img.Refresh()
+	}
+
+	other := widget.NewSelect(names, func(s string) {
+		after = others[slices.Index(names, s)].Images
+		update()
+	})
+	before := widget.NewEntry()
+	before.SetText(c.BeforeLabel)
+	before.OnSubmitted = func(s string) {
+		c.BeforeLabel = s
+		update()
+	}
+	afterLabel := widget.NewEntry()
+	afterLabel.SetText(c.AfterLabel)
+	afterLabel.OnSubmitted = func(s string) {
+		c.AfterLabel = s
+		update()
+	}
+	heatmap := widget.NewCheck("Difference heatmap", func(b bool) {
+		c.Heatmap = b
+		update()
+	})
+	heatmap.Checked = c.Heatmap
+	other.SetSelected(names[0])
+
+	save := widget.NewButton("Save As...", func() {
+		c.BeforeLabel, c.AfterLabel = before.Text, afterLabel.Text
+		d := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := c.Save(writer, e.Images, after); err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			e.showSavedPopUp("Saved successfully")
+		}, e)
+		d.SetFilter(storage.NewMimeTypeFileFilter([]string{"image/jpeg", "image/png"}))
+		d.SetFileName("comparison.png")
+		d.Show()
+	})
+	save.Importance = widget.HighImportance
+
+	form := widget.NewForm(
+		widget.NewFormItem("Compare With", other),
+		widget.NewFormItem("Left Label", before),
+		widget.NewFormItem("Right Label", afterLabel),
+		widget.NewFormItem("", heatmap),
+	)
+	content := container.NewBorder(nil, container.NewBorder(nil, nil, nil, save, form), nil, nil, container.NewScroll(img))
+	d := dialog.NewCustom("Compare", "Close", content, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width*3, imageBaseSize().Height*3))
+	d.Show()
+}
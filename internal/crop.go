@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"image"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+type cropEdge int
+
+const (
+	cropEdgeLeft cropEdge = 1 << iota
+	cropEdgeTop
+	cropEdgeRight
+	cropEdgeBottom
+)
+
+var cropHandleEdges = []cropEdge{
+	cropEdgeLeft | cropEdgeTop, cropEdgeTop, cropEdgeRight | cropEdgeTop, cropEdgeRight,
+	cropEdgeRight | cropEdgeBottom, cropEdgeBottom, cropEdgeLeft | cropEdgeBottom, cropEdgeLeft,
+}
+
+type cropOverlay struct {
+	widget.BaseWidget
+	Image *imageItem
+
+	listener binding.DataListener
+}
+
+func newCropOverlay(image *imageItem) *cropOverlay {
+	o := &cropOverlay{Image: image}
+	o.ExtendBaseWidget(o)
+	o.listener = binding.NewDataListener(o.Refresh)
+	image.Data.Crop.AddListener(o.listener)
+	return o
+}
+
+func (o *cropOverlay) unbind() {
+	o.Image.Data.Crop.RemoveListener(o.listener)
+}
+
+func (o *cropOverlay) MinSize() fyne.Size {
+	return o.Image.pictureSize()
+}
+
+func (o *cropOverlay) scale() float32 {
+	return o.Size().Width / float32(o.Image.Data.Bounds().Dx())
+}
+
+func (o *cropOverlay) CreateRenderer() fyne.WidgetRenderer {
+	th := o.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+
+	r := &cropOverlayRenderer{
+		overlay: o,
+		left:    canvas.NewRectangle(th.Color(theme.ColorNameShadow, v)),
+		right:   canvas.NewRectangle(th.Color(theme.ColorNameShadow, v)),
+		border:  canvas.NewRectangle(nil),
+	}
+	r.border.StrokeColor = th.Color(theme.ColorNamePrimary, v)
+	r.border.StrokeWidth = th.Size(theme.SizeNameInputBorder)
+	for _, e := range cropHandleEdges {
+		r.handles = append(r.handles, newCropHandle(o, e))
+	}
+	return r
+}
+
+type cropOverlayRenderer struct {
+	overlay *cropOverlay
+
+	left, right, border *canvas.Rectangle
+	handles             []*cropHandle
+}
+
+func (r *cropOverlayRenderer) Layout(size fyne.Size) {
+	b := r.overlay.Image.Data.Bounds()
+	c, _ := r.overlay.Image.Data.Crop.Get()
+	scale := r.overlay.scale()
+
+	x0, y0 := float32(c.Min.X-b.Min.X)*scale, float32(c.Min.Y-b.Min.Y)*scale
+	x1, y1 := float32(c.Max.X-b.Min.X)*scale, float32(c.Max.Y-b.Min.Y)*scale
+
+	r.left.Move(fyne.NewPos(0, y0))
+	r.left.Resize(fyne.NewSize(x0, y1-y0))
+	r.right.Move(fyne.NewPos(x1, y0))
+	r.right.Resize(fyne.NewSize(size.Width-x1, y1-y0))
+	r.border.Move(fyne.NewPos(x0, y0))
+	r.border.Resize(fyne.NewSize(x1-x0, y1-y0))
+
+	for _, h := range r.handles {
+		hs := h.MinSize()
+		x, y := (x0+x1)/2, (y0+y1)/2
+		if h.Edges&cropEdgeLeft != 0 {
+			x = x0
+		} else if h.Edges&cropEdgeRight != 0 {
+			x = x1
+		}
+		if h.Edges&cropEdgeTop != 0 {
+			y = y0
+		} else if h.Edges&cropEdgeBottom != 0 {
+			y = y1
+		}
+		h.Move(fyne.NewPos(x-hs.Width/2, y-hs.Height/2))
+		h.Resize(hs)
+	}
+}
+
+func (r *cropOverlayRenderer) MinSize() fyne.Size {
+	return r.overlay.MinSize()
+}
+
+func (r *cropOverlayRenderer) Refresh() {
+	cropping := r.overlay.Image.cropping
+	for _, h := range r.handles {
+		h.Hidden = !cropping
+	}
+	r.border.Hidden = !cropping
+	r.Layout(r.overlay.Size())
+	canvas.Refresh(r.overlay)
+}
+
+func (r *cropOverlayRenderer) Objects() []fyne.CanvasObject {
+	objects := []fyne.CanvasObject{r.left, r.right, r.border}
+	for _, h := range r.handles {
+		objects = append(objects, h)
+	}
+	return objects
+}
+
+func (r *cropOverlayRenderer) Destroy() {}
+
+type cropHandle struct {
+	widget.BaseWidget
+	overlay *cropOverlay
+
+	Edges cropEdge
+
+	dragging bool
+	start    image.Rectangle
+	dx, dy   float32
+}
+
+func newCropHandle(overlay *cropOverlay, edges cropEdge) *cropHandle {
+	h := &cropHandle{overlay: overlay, Edges: edges}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *cropHandle) Cursor() desktop.Cursor {
+	switch h.Edges {
+	case cropEdgeLeft, cropEdgeRight:
+		return desktop.HResizeCursor
+	case cropEdgeTop, cropEdgeBottom:
+		return desktop.VResizeCursor
+	default:
+		return desktop.CrosshairCursor
+	}
+}
+
+func (h *cropHandle) Dragged(e *fyne.DragEvent) {
+	c := h.overlay.Image.Data.Crop
+	if !h.dragging {
+		h.start, _ = c.Get()
+		h.dx, h.dy = 0, 0
+		h.dragging = true
+	}
+	h.dx += e.Dragged.DX
+	h.dy += e.Dragged.DY
+
+	scale := h.overlay.scale()
+	dx := int(math.Round(float64(h.dx / scale)))
+	dy := int(math.Round(float64(h.dy / scale)))
+	r := h.start
+	if h.Edges&cropEdgeLeft != 0 {
+		r.Min.X += dx
+	}
+	if h.Edges&cropEdgeRight != 0 {
+		r.Max.X += dx
+	}
+	if h.Edges&cropEdgeTop != 0 {
+		r.Min.Y += dy
+	}
+	if h.Edges&cropEdgeBottom != 0 {
+		r.Max.Y += dy
+	}
+	c.Set(r)
+}
+
+func (h *cropHandle) DragEnd() {
+	h.dragging = false
+}
+
+func (h *cropHandle) MinSize() fyne.Size {
+	th := h.Theme()
+	return fyne.NewSquareSize(th.Size(theme.SizeNameInlineIcon) / 2)
+}
+
+func (h *cropHandle) CreateRenderer() fyne.WidgetRenderer {
+	th := h.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	rect := canvas.NewRectangle(th.Color(theme.ColorNamePrimary, v))
+	rect.StrokeColor = th.Color(theme.ColorNameBackground, v)
+	rect.StrokeWidth = 1
+	return widget.NewSimpleRenderer(rect)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"image/color"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+func (e editor) newDecorationPanel(preview *imagePreview) fyne.CanvasObject {
+	d, _ := e.Images.Decoration.Get()
+	update := func() {
+		e.Images.Decoration.Set(d)
+		preview.Decorate()
+	}
+
+	var resets []func()
+	slider := func(max float64, value *int) *widget.Slider {
+		s := widget.NewSlider(0, max)
+		s.SetValue(float64(*value))
+		resets = append(resets, func() { s.SetValue(float64(*value)) })
+		s.OnChangeEnded = func(f float64) {
+			*value = int(f)
+			update()
+		}
+		return s
+	}
+	colorButton := func(title string, value *color.NRGBA) *widget.Button {
+		return widget.NewButton("Color...", func() {
+			p := dialog.NewColorPicker(title, "", func(c color.Color) {
+				*value = color.NRGBAModel.Convert(c).(color.NRGBA)
+				update()
+			}, e)
+			p.Advanced = true
+			p.SetColor(*value)
+			p.Show()
+		})
+	}
+
+	gradient := widget.NewCheck("Gradient", func(b bool) {
+		d.Gradient = b
+		update()
+	})
+	gradient.Checked = d.Gradient
+	resets = append(resets, func() { gradient.SetChecked(d.Gradient) })
+
+	load := func(v data.Decoration) {
+		d = v
+		for _, v := range resets {
+			v()
+		}
+		update()
+	}
+
+	// Presets are shared between lists; each list keeps its own decoration.
+	prefs := fyne.CurrentApp().Preferences()
+	presets := widget.NewSelect(nil, nil)
+	refreshPresets := func() {
+		var names []string
+		for name := range data.LoadDecorationPresets(prefs) {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		presets.SetOptions(names)
+	}
+	refreshPresets()
+	presets.PlaceHolder = "Presets"
+	presets.OnChanged = func(name string) {
+		if v, ok := data.LoadDecorationPresets(prefs)[name]; ok {
+			load(v)
+		}
+	}
+	savePreset := widget.NewButton("Save Preset...", func() {
+		name := widget.NewEntry()
+		name.SetText(presets.Selected)
+		dialog.ShowForm("Save Preset", "Save", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", name)}, func(ok bool) {
+			if !ok || name.Text == "" {
+				return
+			}
+			if err := data.SaveDecorationPreset(prefs, name.Text, d); err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			refreshPresets()
+			presets.Selected = name.Text
+			presets.Refresh()
+		}, e)
+	})
+	deletePreset := widget.NewButton("Delete", func() {
+		if presets.Selected == "" {
+			return
+		}
+		data.DeleteDecorationPreset(prefs, presets.Selected)
+		presets.ClearSelected()
+		refreshPresets()
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem("Padding", slider(256, &d.Padding)),
+		widget.NewFormItem("Background", container.NewHBox(
+			colorButton("Background", &d.Background),
+			gradient,
+			colorButton("Gradient End", &d.Background2),
+		)),
+		widget.NewFormItem("Corners", slider(128, &d.CornerRadius)),
+		widget.NewFormItem("Shadow", container.NewBorder(nil, nil, nil, colorButton("Shadow", &d.ShadowColor), slider(128, &d.ShadowRadius))),
+		widget.NewFormItem("Offset", slider(64, &d.ShadowOffset)),
+		widget.NewFormItem("Border", container.NewBorder(nil, nil, nil, colorButton("Border", &d.BorderColor), slider(32, &d.BorderWidth))),
+		widget.NewFormItem("", widget.NewButton("Reset", func() { load(data.DefaultDecoration) })),
+	)
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(savePreset, deletePreset), presets),
+		form,
+	)
+}
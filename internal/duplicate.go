@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	prefDuplicateThreshold    = "duplicateThreshold"
+	defaultDuplicateThreshold = 0.95
+)
+
+func duplicateThreshold() float64 {
+	return fyne.CurrentApp().Preferences().FloatWithFallback(prefDuplicateThreshold, defaultDuplicateThreshold)
+}
+
+// ShowDuplicatesDialog only looks for duplicates again once the slider is
+// released, since every search compares each pair of images.
+func (e editor) ShowDuplicatesDialog() {
+	prefs := fyne.CurrentApp().Preferences()
+	slider := widget.NewSlider(0.8, 1)
+	slider.Step = 0.01
+	slider.Value = duplicateThreshold()
+	status := widget.NewLabel("")
+
+	dups := e.Images.Duplicates(slider.Value)
+	showStatus := func(v float64) {
+		status.SetText(fmt.Sprintf("%d duplicates at %.0f%% similarity", len(dups), v*100))
+	}
+	showStatus(slider.Value)
+	slider.OnChanged = func(v float64) {
+		status.SetText(fmt.Sprintf("%.0f%% similarity", v*100))
+	}
+	slider.OnChangeEnded = func(v float64) {
+		prefs.SetFloat(prefDuplicateThreshold, v)
+		dups = e.Images.Duplicates(v)
+		showStatus(v)
+		e.list.Refresh()
+	}
+
+	d := dialog.NewCustomConfirm("Find Duplicates", "Remove", "Cancel",
+		container.NewVBox(slider, status),
+		func(ok bool) {
+			if ok && len(dups) > 0 {
+				e.Images.RemoveAll(dups)
+			}
+		}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+func (e editor) ShowFolderOpenDialog(callback func(uris []fyne.URI)) {
+	d := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, e)
+			return
+		}
+		if dir == nil {
+			return
+		}
+		e.ShowFolderImportDialog(dir, callback)
+	}, e)
+	d.Show()
+}
+
+// ShowFolderImportDialog lists and sorts the folder in the background, as
+// sorting by capture time reads the metadata of every file.
+func (e editor) ShowFolderImportDialog(dir fyne.URI, callback func(uris []fyne.URI)) {
+	go func() {
+		uris, err := data.ListImages(dir)
+		if err != nil {
+			dialog.ShowError(err, e)
+			return
+		}
+		if len(uris) == 0 {
+			dialog.ShowInformation("Import "+dir.Name(), "No supported images were found in this folder.", e)
+			return
+		}
+		data.SortURIs(uris, data.SortByName)
+		e.showFolderImportDialog(dir, uris, callback)
+	}()
+}
+
+// showFolderImportDialog sorts in the background as well. uris is only
+// ever replaced, never sorted in place, so a reader holding the lock gets a
+// complete order, and Add stays disabled until a new order is in.
+func (e editor) showFolderImportDialog(dir fyne.URI, uris []fyne.URI, callback func(uris []fyne.URI)) {
+	var mu sync.Mutex
+	current := func() []fyne.URI {
+		mu.Lock()
+		defer mu.Unlock()
+		return uris
+	}
+	list := widget.NewList(
+		func() int { return len(current()) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if uris := current(); id < len(uris) {
+				obj.(*widget.Label).SetText(strings.TrimPrefix(uris[id].String(), dir.String()+"/"))
+			}
+		},
+	)
+
+	var d *dialog.CustomDialog
+	add := widget.NewButton("Add", func() {
+		d.Hide()
+		callback(current())
+	})
+	add.Importance = widget.HighImportance
+	cancel := widget.NewButton("Cancel", func() { d.Hide() })
+
+	orders := make([]string, len(data.SortOrders))
+	for i, v := range data.SortOrders {
+		orders[i] = v.String()
+	}
+	order := widget.NewSelect(orders, nil)
+	order.SetSelectedIndex(int(data.SortByName))
+	order.OnChanged = func(s string) {
+		order.Disable()
+		add.Disable()
+		sorted := slices.Clone(current())
+		go func() {
+			for _, v := range data.SortOrders {
+				if v.String() == s {
+					data.SortURIs(sorted, v)
+				}
+			}
+			mu.Lock()
+			uris = sorted
+			mu.Unlock()
+			list.Refresh()
+			list.ScrollToTop()
+			order.Enable()
+			add.Enable()
+		}()
+	}
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("Sort by"), nil, order), nil, nil, nil,
+		list,
+	)
+	d = dialog.NewCustomWithoutButtons("Import "+dir.Name(), content, e)
+	d.SetButtons([]fyne.CanvasObject{cancel, add})
+	d.Resize(fyne.NewSize(imageBaseSize().Width, imageBaseSize().Width))
+	d.Show()
+}
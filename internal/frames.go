@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+const (
+	prefFrameInterval     = "frameInterval"
+	prefFrameMinScroll    = "frameMinScroll"
+	defaultFrameInterval  = 1
+	defaultFrameMinScroll = 100
+)
+
+func (e editor) ShowFrameImportDialog(uri fyne.URI, callback func(images []*data.Image)) {
+	prefs := fyne.CurrentApp().Preferences()
+	sampling := data.FrameSampling{
+		Interval:  prefs.IntWithFallback(prefFrameInterval, defaultFrameInterval),
+		MinScroll: prefs.IntWithFallback(prefFrameMinScroll, defaultFrameMinScroll),
+	}
+
+	intEntry := func(value *int) *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetText(strconv.Itoa(*value))
+		entry.OnChanged = func(s string) {
+			if v, err := strconv.Atoi(s); err == nil && v >= 0 {
+				*value = v
+			}
+		}
+		return entry
+	}
+	content := widget.NewForm(
+		widget.NewFormItem("Every Nth frame", intEntry(&sampling.Interval)),
+		widget.NewFormItem("Min scroll (px)", intEntry(&sampling.MinScroll)),
+	)
+
+	d := dialog.NewCustomConfirm(fmt.Sprintf("Import Frames from %s", uri.Name()), "Import", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		prefs.SetInt(prefFrameInterval, sampling.Interval)
+		prefs.SetInt(prefFrameMinScroll, sampling.MinScroll)
+		go func() {
+			space, _ := e.Images.ColorSpace.Get()
+			images, err := data.LoadFrames(uri, sampling, space)
+			if err != nil {
+				dialog.ShowError(err, e)
+			}
+			if len(images) > 0 {
+				callback(images)
+			}
+		}()
+	}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
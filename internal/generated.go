@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+func (e editor) newInsertMenuItem() *fyne.MenuItem {
+	var items []*fyne.MenuItem
+	for _, kind := range data.GeneratedKinds {
+		items = append(items, &fyne.MenuItem{Label: kind.String() + "...", Action: func() {
+			g := data.NewGenerated(kind, e.Images.Width())
+			if kind == data.GeneratedStep {
+				g.Step = e.Images.NextStep()
+			}
+			e.ShowGeneratedDialog(g, func(g data.Generated) {
+				index := e.Images.Length()
+				if selected := e.list.Selected(); len(selected) > 0 {
+					index = e.Images.IndexOf(selected[len(selected)-1]) + 1
+				}
+				e.Images.Insert(index, data.NewGeneratedImage(g))
+			})
+		}})
+	}
+	return &fyne.MenuItem{Label: "Insert", ChildMenu: fyne.NewMenu("", items...)}
+}
+
+func (e editor) ShowGeneratedDialog(g data.Generated, callback func(g data.Generated)) {
+	intEntry := func(value *int) *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetText(strconv.Itoa(*value))
+		entry.OnChanged = func(s string) {
+			if v, err := strconv.Atoi(s); err == nil && v >= 0 {
+				*value = v
+			}
+		}
+		return entry
+	}
+	colorButton := func(title string, value *color.NRGBA) *widget.Button {
+		return widget.NewButton(title+"...", func() {
+			d := dialog.NewColorPicker(title, "", func(c color.Color) {
+				*value = color.NRGBAModel.Convert(c).(color.NRGBA)
+			}, e)
+			d.Advanced = true
+			d.SetColor(*value)
+			d.Show()
+		})
+	}
+
+	heading := widget.NewEntry()
+	heading.SetText(g.Heading)
+	heading.OnChanged = func(s string) { g.Heading = s }
+	body := widget.NewMultiLineEntry()
+	body.SetText(g.Body)
+	body.OnChanged = func(s string) { g.Body = s }
+
+	form := widget.NewForm()
+	switch g.Kind {
+	case data.GeneratedBanner:
+		form.Append("Heading", heading)
+		form.Append("Body", body)
+		form.Append("Min Height", intEntry(&g.Height))
+	case data.GeneratedSpacer:
+		form.Append("Height", intEntry(&g.Height))
+	case data.GeneratedStep:
+		form.Append("Number", intEntry(&g.Step))
+		form.Append("Label", heading)
+	}
+	form.Append("Width", intEntry(&g.Width))
+	colors := container.NewHBox(colorButton("Color", &g.Color))
+	if g.Kind != data.GeneratedSpacer {
+		colors.Add(colorButton("Background", &g.Background))
+	}
+	form.Append("", colors)
+
+	d := dialog.NewCustomConfirm(g.Kind.String(), "OK", "Cancel", form, func(ok bool) {
+		if ok {
+			callback(g)
+		}
+	}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
@@ -16,6 +16,7 @@ type Globalizer struct {
 	MouseOver  binding.Bool
 	MousePos   bindingx.Typed[fyne.Position]
 	MousePress binding.Bool
+	MouseMod   bindingx.Typed[fyne.KeyModifier]
 }
 
 func NewGlobalizer(content fyne.CanvasObject) *Globalizer {
@@ -24,7 +25,9 @@ func NewGlobalizer(content fyne.CanvasObject) *Globalizer {
 		MouseOver:  binding.NewBool(),
 		MousePos:   bindingx.NewTyped[fyne.Position](),
 		MousePress: binding.NewBool(),
+		MouseMod:   bindingx.NewTyped[fyne.KeyModifier](),
 	}
+	g.MouseMod.Set(0)
 	g.ExtendBaseWidget(g)
 	return g
 }
@@ -54,6 +57,7 @@ func (g *Globalizer) MouseOut() {
 }
 
 func (g *Globalizer) MouseDown(e *desktop.MouseEvent) {
+	g.MouseMod.Set(e.Modifier)
 	g.MousePress.Set(true)
 }
 
@@ -1,8 +1,11 @@
 package internal
 
 import (
+	"fmt"
 	"image"
 	"math"
+	"slices"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -24,12 +27,26 @@ type imageList struct {
 	Editor *editor
 
 	container *fyne.Container
+
+	selection  map[*data.Image]bool
+	anchor     *data.Image
+	duplicates map[*data.Image]bool
+	heatmap    bool
+
+	// dupList and dupThreshold are what duplicates was last searched for.
+	// The search runs in the background, so they and duplicates are locked.
+	dupMu        sync.Mutex
+	dupList      []*data.Image
+	dupThreshold float64
 }
 
 func newImageList(e *editor, g *Globalizer) *imageList {
 	l := &imageList{
 		Editor:    e,
 		container: container.New(imageListLayout{layout.NewVBoxLayout()}),
+		selection: map[*data.Image]bool{},
+
+		dupThreshold: -1,
 	}
 	l.ExtendBaseWidget(l)
 
@@ -38,20 +55,179 @@ func newImageList(e *editor, g *Globalizer) *imageList {
 	g.MousePos.AddListener(listener)
 
 	e.Images.AddListener(binding.NewDataListener(l.Refresh))
+	e.Images.Separator.AddListener(binding.NewDataListener(l.Refresh))
+	e.Images.Mosaic.AddListener(binding.NewDataListener(l.Refresh))
 	return l
 }
 
+func (l *imageList) gridColumns() int {
+	if m, _ := l.Editor.Images.Mosaic.Get(); m.Enabled {
+		return m.Columns
+	}
+	return 1
+}
+
 func (l *imageList) Refresh() {
+	l.refreshDuplicates()
+
+	for _, obj := range l.container.Objects {
+		if item, ok := obj.(*imageItem); ok {
+			item.unbind()
+		}
+	}
 	l.container.RemoveAll()
 	val, _ := l.Editor.Images.Get()
+	m, _ := l.Editor.Images.Mosaic.Get()
+	grid := m.Enabled
+	if grid {
+		l.container.Layout = layout.NewGridLayoutWithColumns(l.gridColumns())
+	} else {
+		l.container.Layout = imageListLayout{layout.NewVBoxLayout()}
+	}
 	for i, v := range val {
-		l.container.Add(newImageItem(l, i, v))
+		item := newImageItem(l, i, v)
+		if i > 0 && !grid {
+			item.seam = newImageSeam(l, val[i-1], v)
+			l.container.Add(item.seam)
+		}
+		l.container.Add(item)
 	}
 	l.container.Add(newImageAddButton(l.Editor.ShowImageAddDialog))
 
+	for img := range l.selection {
+		if !slices.Contains(val, img) {
+			delete(l.selection, img)
+		}
+	}
+	if !slices.Contains(val, l.anchor) {
+		l.anchor = nil
+	}
+
 	l.BaseWidget.Refresh()
 }
 
+// refreshDuplicates searches for duplicates in the background when the
+// images or the threshold have changed since the last search, and updates
+// the badges when done.
+func (l *imageList) refreshDuplicates() {
+	val, _ := l.Editor.Images.Get()
+	threshold := duplicateThreshold()
+	l.dupMu.Lock()
+	defer l.dupMu.Unlock()
+	if threshold == l.dupThreshold && slices.Equal(val, l.dupList) {
+		return
+	}
+	l.dupList, l.dupThreshold = val, threshold
+	go func() {
+		found := map[*data.Image]bool{}
+		for _, v := range data.FindDuplicates(val, threshold) {
+			found[v] = true
+		}
+		l.dupMu.Lock()
+		current := threshold == l.dupThreshold && slices.Equal(val, l.dupList)
+		if current {
+			l.duplicates = found
+		}
+		l.dupMu.Unlock()
+		if current {
+			l.refreshItemBadges()
+		}
+	}()
+}
+
+func (l *imageList) isDuplicate(img *data.Image) bool {
+	l.dupMu.Lock()
+	defer l.dupMu.Unlock()
+	return l.duplicates[img]
+}
+
+func (l *imageList) Selected() []*data.Image {
+	val, _ := l.Editor.Images.Get()
+	return slices.DeleteFunc(val, func(v *data.Image) bool { return !l.selection[v] })
+}
+
+func (l *imageList) IsSelected(img *data.Image) bool {
+	return l.selection[img]
+}
+
+func (l *imageList) SetSelection(images []*data.Image) {
+	clear(l.selection)
+	for _, v := range images {
+		l.selection[v] = true
+	}
+	l.refreshItemSelection()
+}
+
+func (l *imageList) Select(img *data.Image, modifier fyne.KeyModifier) {
+	switch {
+	case modifier&fyne.KeyModifierShift != 0 && l.anchor != nil:
+		from, to := l.Editor.Images.IndexOf(l.anchor), l.Editor.Images.IndexOf(img)
+		if from > to {
+			from, to = to, from
+		}
+		val, _ := l.Editor.Images.Get()
+		clear(l.selection)
+		for _, v := range val[from : to+1] {
+			l.selection[v] = true
+		}
+	case modifier&fyne.KeyModifierShortcutDefault != 0:
+		if l.selection[img] {
+			delete(l.selection, img)
+		} else {
+			l.selection[img] = true
+		}
+		l.anchor = img
+	default:
+		sole := len(l.selection) == 1 && l.selection[img]
+		clear(l.selection)
+		if !sole {
+			l.selection[img] = true
+		}
+		l.anchor = img
+	}
+	l.refreshItemSelection()
+}
+
+func (l *imageList) refreshItemSelection() {
+	for _, obj := range l.container.Objects {
+		if item, ok := obj.(*imageItem); ok {
+			item.RefreshSelection()
+		}
+	}
+}
+
+func (l *imageList) refreshItemBadges() {
+	for _, obj := range l.container.Objects {
+		if item, ok := obj.(*imageItem); ok {
+			item.RefreshBadges()
+		}
+	}
+}
+
+func (l *imageList) refreshAnnotations() {
+	for _, obj := range l.container.Objects {
+		if item, ok := obj.(*imageItem); ok && item.annotationLayer != nil {
+			item.annotationLayer.Refresh()
+		}
+	}
+}
+
+func (l *imageList) refreshHeatmaps() {
+	for _, obj := range l.container.Objects {
+		if item, ok := obj.(*imageItem); ok {
+			item.RefreshHeatmap()
+		}
+	}
+}
+
+func (l *imageList) refreshSeamBadges() {
+	for _, obj := range l.container.Objects {
+		if seam, ok := obj.(*imageSeam); ok {
+			seam.RefreshBadge()
+		}
+	}
+}
+
 func (l *imageList) refreshItemSliders() {
 	for _, obj := range l.container.Objects {
 		if item, ok := obj.(*imageItem); ok {
@@ -112,68 +288,288 @@ type imageItem struct {
 	Index int
 	Data  *data.Image
 
+	picture         *canvas.Image
+	heatmap         *canvas.Image
 	sliderContainer *fyne.Container
+	selection       *canvas.Rectangle
+	cropOverlay     *cropOverlay
+	annotationLayer *annotationLayer
+	badges          *fyne.Container
+
+	redactions binding.DataListener
+
+	// seam is the seam above the item, if any.
+	seam *imageSeam
+
+	cropping bool
 }
 
 func newImageItem(list *imageList, index int, data *data.Image) *imageItem {
 	i := &imageItem{List: list, Index: index, Data: data}
 	i.ExtendBaseWidget(i)
+	i.redactions = binding.NewDataListener(func() {
+		if i.picture != nil {
+			i.picture.Image = i.Data.Redacted()
+			i.picture.Refresh()
+		}
+		i.RefreshBadges()
+	})
+	data.Redactions.AddListener(i.redactions)
 	return i
 }
 
+// unbind stops the item from following its image once the list has
+// replaced it.
+func (i *imageItem) unbind() {
+	i.Data.Redactions.RemoveListener(i.redactions)
+	if i.cropOverlay != nil {
+		i.cropOverlay.unbind()
+	}
+	if i.annotationLayer != nil {
+		i.annotationLayer.unbind()
+	}
+	if i.seam != nil {
+		i.seam.unbind()
+	}
+}
+
+func (i *imageItem) Tapped(*fyne.PointEvent) {
+	var modifier fyne.KeyModifier
+	if g := GlobalizerForObject(i); g != nil {
+		modifier, _ = g.MouseMod.Get()
+	}
+	i.List.Select(i.Data, modifier)
+}
+
 func (i *imageItem) TappedSecondary(e *fyne.PointEvent) {
+	if selected := i.List.Selected(); len(selected) > 1 && i.List.IsSelected(i.Data) {
+		i.showSelectionMenu(selected, e.AbsolutePosition)
+		return
+	}
+
 	canMoveUp := i.Index > 0
 	canMoveDown := i.Index < i.List.Editor.Images.Length()-1
-	widget.ShowPopUpMenuAtPosition(fyne.NewMenu(i.Data.URI.Name(),
+	var edit []*fyne.MenuItem
+	if g := i.Data.Generated; g != nil {
+		edit = append(edit, &fyne.MenuItem{Label: "Edit...", Action: func() {
+			i.List.Editor.ShowGeneratedDialog(*g, func(g data.Generated) {
+				i.List.Editor.Images.Replace(i.Data, data.NewGeneratedImage(g))
+			})
+		}}, fyne.NewMenuItemSeparator())
+	}
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu(i.Data.Name(), append(edit,
 		&fyne.MenuItem{Icon: theme.MoveUpIcon(), Label: "Move Up", Action: func() {
 			if canMoveUp {
-				left, _ := i.List.Editor.Images.GetValue(i.Index - 1)
-				i.List.Editor.Images.SetValue(i.Index, left)
-				i.List.Editor.Images.SetValue(i.Index-1, i.Data)
-				i.List.Refresh()
+				i.List.Editor.Images.Swap(i.Index, i.Index-1)
 			}
 		}, Disabled: !canMoveUp},
 		&fyne.MenuItem{Icon: theme.MoveDownIcon(), Label: "Move Down", Action: func() {
 			if canMoveDown {
-				right, _ := i.List.Editor.Images.GetValue(i.Index + 1)
-				i.List.Editor.Images.SetValue(i.Index, right)
-				i.List.Editor.Images.SetValue(i.Index+1, i.Data)
-				i.List.Refresh()
+				i.List.Editor.Images.Swap(i.Index, i.Index+1)
 			}
 		}, Disabled: !canMoveDown},
+		&fyne.MenuItem{Label: "Move to Top", Action: func() {
+			i.List.Editor.Images.MoveToTop([]*data.Image{i.Data})
+		}, Disabled: !canMoveUp},
+		&fyne.MenuItem{Label: "Move to Bottom", Action: func() {
+			i.List.Editor.Images.MoveToBottom([]*data.Image{i.Data})
+		}, Disabled: !canMoveDown},
+		fyne.NewMenuItemSeparator(),
+		&fyne.MenuItem{Icon: theme.ContentCutIcon(), Label: "Crop", Checked: i.cropping, Action: func() {
+			i.cropping = !i.cropping
+			i.cropOverlay.Refresh()
+		}},
+		&fyne.MenuItem{Label: "Reset Crop", Action: func() {
+			i.Data.Crop.Reset()
+		}},
+		&fyne.MenuItem{Label: "Clear Redactions", Action: func() {
+			i.Data.Redactions.Set(nil)
+		}, Disabled: i.Data.Redactions.Length() == 0},
+		i.newTransformMenuItem(),
 		fyne.NewMenuItemSeparator(),
 		&fyne.MenuItem{Icon: theme.DeleteIcon(), Label: "Remove", Action: func() {
-			i.List.Editor.Images.Remove(i.Data)
+			i.List.Editor.Images.RemoveAll([]*data.Image{i.Data})
 		}},
-	), fyne.CurrentApp().Driver().CanvasForObject(i), e.AbsolutePosition)
+	)...), fyne.CurrentApp().Driver().CanvasForObject(i), e.AbsolutePosition)
+}
+
+func (i *imageItem) newTransformMenuItem() *fyne.MenuItem {
+	t, _ := i.Data.Transform.Get()
+	set := func(t data.Transform) func() {
+		return func() {
+			i.Data.SetTransform(t)
+			i.List.Refresh()
+		}
+	}
+
+	var scales []*fyne.MenuItem
+	for _, v := range []float64{0.25, 0.5, 1, 1.5, 2} {
+		scaled := t
+		scaled.Scale = v
+		scales = append(scales, &fyne.MenuItem{
+			Label:   fmt.Sprintf("%g%%", v*100),
+			Checked: t.Scale == v || t.Scale == 0 && v == 1,
+			Action:  set(scaled),
+		})
+	}
+
+	return &fyne.MenuItem{Label: "Transform", ChildMenu: fyne.NewMenu("",
+		&fyne.MenuItem{Icon: theme.MediaReplayIcon(), Label: "Rotate Left", Action: set(t.RotateBy(-1))},
+		&fyne.MenuItem{Icon: theme.ViewRefreshIcon(), Label: "Rotate Right", Action: set(t.RotateBy(1))},
+		&fyne.MenuItem{Label: "Flip Horizontal", Action: set(t.Flip(true))},
+		&fyne.MenuItem{Label: "Flip Vertical", Action: set(t.Flip(false))},
+		&fyne.MenuItem{Label: "Scale", ChildMenu: fyne.NewMenu("", scales...)},
+		fyne.NewMenuItemSeparator(),
+		&fyne.MenuItem{Label: "Reset Transform", Action: set(data.Transform{}), Disabled: t.IsIdentity()},
+	)}
+}
+
+func (i *imageItem) showSelectionMenu(selected []*data.Image, pos fyne.Position) {
+	images := i.List.Editor.Images
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu(fmt.Sprintf("%d images", len(selected)),
+		&fyne.MenuItem{Icon: theme.MoveUpIcon(), Label: "Move to Top", Action: func() {
+			images.MoveToTop(selected)
+		}},
+		&fyne.MenuItem{Icon: theme.MoveDownIcon(), Label: "Move to Bottom", Action: func() {
+			images.MoveToBottom(selected)
+		}},
+		&fyne.MenuItem{Icon: theme.ViewRefreshIcon(), Label: "Reverse", Action: func() {
+			images.ReverseSubset(selected)
+		}},
+		fyne.NewMenuItemSeparator(),
+		&fyne.MenuItem{Label: "Apply These Trims", Action: func() {
+			tl, _ := i.Data.TrimLeading.Get()
+			tt, _ := i.Data.TrimTrailing.Get()
+			for _, v := range selected {
+				v.TrimLeading.Set(tl)
+				v.TrimTrailing.Set(tt)
+			}
+		}},
+		&fyne.MenuItem{Icon: theme.DocumentSaveIcon(), Label: "Save Selection As...", Action: func() {
+			i.List.Editor.ShowSelectionSaveDialog()
+		}},
+		fyne.NewMenuItemSeparator(),
+		&fyne.MenuItem{Icon: theme.DeleteIcon(), Label: "Remove", Action: func() {
+			images.RemoveAll(selected)
+		}},
+	), fyne.CurrentApp().Driver().CanvasForObject(i), pos)
 }
 
 func (i *imageItem) MinSize() fyne.Size {
-	return imageSizeByBounds(i.Data.Image.Bounds()).
+	return i.pictureSize().
 		AddWidthHeight((&imageSliderThumb{}).MinSize().Width*2, 0)
 }
 
+// pictureSize shrinks the picture so a mosaic row fits the width of a
+// single item.
+func (i *imageItem) pictureSize() fyne.Size {
+	size := imageSizeByBounds(i.Data.Bounds())
+	if n := i.List.gridColumns(); n > 1 {
+		return fyne.NewSize(size.Width/float32(n), size.Height/float32(n))
+	}
+	return size
+}
+
 func (i *imageItem) RefreshSliders() {
 	i.sliderContainer.Refresh()
 }
 
+func (i *imageItem) RefreshBadges() {
+	if i.badges == nil {
+		return
+	}
+	i.badges.RemoveAll()
+	if i.List.isDuplicate(i.Data) {
+		i.badges.Add(newImageBadge("Duplicate", theme.ColorNameWarning))
+	}
+	if i.Data.Redactions.Length() > 0 {
+		i.badges.Add(newImageBadge("Redacted", theme.ColorNameError))
+	}
+}
+
+func (i *imageItem) RefreshHeatmap() {
+	if i.heatmap == nil {
+		return
+	}
+	if !i.List.heatmap {
+		i.heatmap.Hide()
+		return
+	}
+	i.heatmap.Image = i.List.Editor.Images.SeamHeatmap(i.Data)
+	i.heatmap.Show()
+	i.heatmap.Refresh()
+}
+
+func (i *imageItem) RefreshSelection() {
+	if i.selection == nil {
+		return
+	}
+	if i.List.IsSelected(i.Data) {
+		i.selection.Show()
+	} else {
+		i.selection.Hide()
+	}
+}
+
 func (i *imageItem) CreateRenderer() fyne.WidgetRenderer {
-	image := canvas.NewImageFromImage(i.Data.Image)
+	image := canvas.NewImageFromImage(i.Data.Redacted())
 	image.FillMode = canvas.ImageFillContain
 	image.ScaleMode = canvas.ImageScaleFastest
-	image.SetMinSize(imageSizeByBounds(i.Data.Image.Bounds()))
+	image.SetMinSize(i.pictureSize())
+	i.picture = image
+
+	i.heatmap = canvas.NewImageFromImage(nil)
+	i.heatmap.FillMode = canvas.ImageFillContain
+	i.heatmap.ScaleMode = canvas.ImageScaleFastest
+	i.heatmap.SetMinSize(i.pictureSize())
+	i.RefreshHeatmap()
+
+	th := i.Theme()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	i.selection = canvas.NewRectangle(th.Color(theme.ColorNameSelection, v))
+	i.selection.StrokeColor = th.Color(theme.ColorNamePrimary, v)
+	i.selection.StrokeWidth = th.Size(theme.SizeNameInputBorder) * 2
+	i.selection.SetMinSize(image.MinSize())
+	i.RefreshSelection()
 
 	i.sliderContainer = container.NewWithoutLayout(
 		newImageSlider(i, sliderDirectionDown),
 		newImageSlider(i, sliderDirectionUp),
 	)
+	i.cropOverlay = newCropOverlay(i)
+	i.annotationLayer = newAnnotationLayer(i)
+
+	i.badges = container.NewHBox()
+	i.RefreshBadges()
+	thumbWidth := (&imageSliderThumb{}).MinSize().Width
+	padding := th.Size(theme.SizeNamePadding)
 
 	return widget.NewSimpleRenderer(container.NewStack(
 		container.NewCenter(image),
+		container.NewCenter(i.heatmap),
+		container.NewCenter(i.selection),
+		container.NewCenter(i.annotationLayer),
+		container.New(
+			layout.NewCustomPaddedLayout(padding, padding, thumbWidth+padding, thumbWidth+padding),
+			container.NewVBox(i.badges),
+		),
 		i.sliderContainer,
+		container.NewCenter(i.cropOverlay),
 	))
 }
 
+func newImageBadge(text string, clr fyne.ThemeColorName) fyne.CanvasObject {
+	th := theme.Current()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+
+	bg := canvas.NewRectangle(th.Color(clr, v))
+	bg.CornerRadius = th.Size(theme.SizeNameSelectionRadius)
+	label := canvas.NewText(text, th.Color(theme.ColorNameBackground, v))
+	label.TextStyle = fyne.TextStyle{Bold: true}
+	label.TextSize = th.Size(theme.SizeNameCaptionText)
+	return container.NewStack(bg, container.NewPadded(label))
+}
+
 type sliderDirection int
 
 const (
@@ -224,7 +620,7 @@ type imageSliderRenderer struct {
 func (r *imageSliderRenderer) Refresh() {
 	img := r.slider.Image
 
-	scale := img.Size().Height / float32(img.Data.Image.Bounds().Dy())
+	scale := img.Size().Height / float32(img.Data.Bounds().Dy())
 	offset := (&imageSliderThumb{}).MinSize().Height / 2
 	switch r.slider.Direction {
 	case sliderDirectionDown:
@@ -233,7 +629,7 @@ func (r *imageSliderRenderer) Refresh() {
 		r.slider.Resize(fyne.NewSize(img.Size().Width, float32(v)*scale+offset))
 	case sliderDirectionUp:
 		v, _ := img.Data.TrimTrailing.Get()
-		r.slider.Move(fyne.NewPos(0, float32(img.Data.Image.Bounds().Dy()-v)*scale-offset))
+		r.slider.Move(fyne.NewPos(0, float32(img.Data.Bounds().Dy()-v)*scale-offset))
 		r.slider.Resize(fyne.NewSize(img.Size().Width, float32(v)*scale+offset))
 	}
 
@@ -314,7 +710,7 @@ func (t *imageSliderThumb) Cursor() desktop.Cursor {
 func (t *imageSliderThumb) Dragged(e *fyne.DragEvent) {
 	s := t.slider
 
-	scaled := int(e.Dragged.DY * float32(s.Image.Data.Image.Bounds().Dy()) / s.Image.Size().Height)
+	scaled := int(e.Dragged.DY * float32(s.Image.Data.Bounds().Dy()) / s.Image.Size().Height)
 	switch s.Direction {
 	case sliderDirectionDown:
 		val, _ := s.Image.Data.TrimLeading.Get()
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+func (e editor) ShowMosaicDialog() {
+	m, _ := e.Images.Mosaic.Get()
+
+	columns := widget.NewEntry()
+	columns.SetText(strconv.Itoa(m.Columns))
+	columns.OnChanged = func(s string) {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			m.Columns = v
+		}
+	}
+	infer := widget.NewButton("Infer from Overlaps", func() {
+		columns.SetText(strconv.Itoa(e.Images.InferMosaicColumns()))
+	})
+	enabled := widget.NewCheck("Arrange images in a grid", func(b bool) {
+		m.Enabled = b
+		if b {
+			columns.Enable()
+			infer.Enable()
+		} else {
+			columns.Disable()
+			infer.Disable()
+		}
+	})
+	enabled.Checked = m.Enabled
+	enabled.OnChanged(m.Enabled)
+
+	content := widget.NewForm(
+		widget.NewFormItem("", enabled),
+		widget.NewFormItem("Columns", container.NewBorder(nil, nil, nil, infer, columns)),
+	)
+	d := dialog.NewCustomConfirm("Mosaic", "Apply", "Cancel", content, func(ok bool) {
+		if ok {
+			e.Images.Mosaic.Set(m)
+		}
+	}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
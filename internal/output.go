@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+const prefColorSpace = "colorSpace"
+
+func (e editor) ShowOutputDialog() {
+	out, _ := e.Images.Output.Get()
+	size := widget.NewLabel("")
+	src := e.Images.StampedSize()
+	update := func() {
+		dst := out.Size(src)
+		text := fmt.Sprintf("%d × %d → %d × %d", src.X, src.Y, dst.X, dst.Y)
+		if out.Retina {
+			r := out.RetinaSize(src)
+			text += fmt.Sprintf(" (@2x %d × %d)", r.X, r.Y)
+		}
+		size.SetText(text)
+	}
+
+	intEntry := func(value *int, placeHolder string) *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder(placeHolder)
+		if *value > 0 {
+			entry.SetText(strconv.Itoa(*value))
+		}
+		entry.OnChanged = func(s string) {
+			v, err := strconv.Atoi(s)
+			if s == "" {
+				v, err = 0, nil
+			}
+			if err == nil && v >= 0 {
+				*value = v
+				update()
+			}
+		}
+		return entry
+	}
+
+	scale := widget.NewEntry()
+	scale.SetPlaceHolder("100")
+	if out.Scale > 0 {
+		scale.SetText(strconv.FormatFloat(out.Scale*100, 'f', -1, 64))
+	}
+	scale.OnChanged = func(s string) {
+		v, err := strconv.ParseFloat(s, 64)
+		if s == "" {
+			v, err = 0, nil
+		}
+		if err == nil && v >= 0 {
+			out.Scale = v / 100
+			update()
+		}
+	}
+
+	megapixels := widget.NewEntry()
+	megapixels.SetPlaceHolder("No limit")
+	if out.MaxPixels > 0 {
+		megapixels.SetText(strconv.FormatFloat(float64(out.MaxPixels)/1e6, 'f', -1, 64))
+	}
+	megapixels.OnChanged = func(s string) {
+		v, err := strconv.ParseFloat(s, 64)
+		if s == "" {
+			v, err = 0, nil
+		}
+		if err == nil && v >= 0 {
+			out.MaxPixels = int(v * 1e6)
+			update()
+		}
+	}
+
+	resamplers := make([]string, len(data.Resamplers))
+	for i, v := range data.Resamplers {
+		resamplers[i] = v.String()
+	}
+	resampler := widget.NewSelect(resamplers, func(s string) {
+		out.Resampler = data.Resamplers[slices.Index(resamplers, s)]
+	})
+	resampler.SetSelected(out.Resampler.String())
+
+	retina := widget.NewCheck("Also write @2x", func(b bool) {
+		out.Retina = b
+		update()
+	})
+	retina.Checked = out.Retina
+	layoutMap := widget.NewCheck("Write layout map (.layout.json)", func(b bool) { out.LayoutMap = b })
+	layoutMap.Checked = out.LayoutMap
+	embed := widget.NewCheck("Embed color profile", func(b bool) { out.EmbedProfile = b })
+	embed.Checked = out.EmbedProfile
+
+	colors := intEntry(&out.Optimize.Colors, "256")
+	dither := widget.NewCheck("Dither", func(b bool) { out.Optimize.Dither = b })
+	dither.Checked = out.Optimize.Dither
+	lossy := widget.NewCheck("Reduce colors (lossy)", func(b bool) {
+		out.Optimize.Lossy = b
+		if b && out.Optimize.Enabled {
+			colors.Enable()
+			dither.Enable()
+		} else {
+			colors.Disable()
+			dither.Disable()
+		}
+	})
+	optimize := widget.NewCheck("Optimize PNG size", func(b bool) {
+		out.Optimize.Enabled = b
+		if b {
+			lossy.Enable()
+		} else {
+			lossy.Disable()
+		}
+		lossy.OnChanged(out.Optimize.Lossy)
+	})
+	lossy.Checked = out.Optimize.Lossy
+	optimize.Checked = out.Optimize.Enabled
+	optimize.OnChanged(out.Optimize.Enabled)
+
+	papers := make([]string, len(data.PaperSizes))
+	for i, v := range data.PaperSizes {
+		papers[i] = v.String()
+	}
+	paper := widget.NewSelect(papers, func(s string) {
+		out.PDF.Paper = data.PaperSizes[slices.Index(papers, s)]
+	})
+	paper.SetSelected(out.PDF.Paper.String())
+	margin := widget.NewEntry()
+	margin.SetPlaceHolder("0")
+	if out.PDF.Margin > 0 {
+		margin.SetText(strconv.FormatFloat(out.PDF.Margin, 'f', -1, 64))
+	}
+	margin.OnChanged = func(s string) {
+		v, err := strconv.ParseFloat(s, 64)
+		if s == "" {
+			v, err = 0, nil
+		}
+		if err == nil && v >= 0 {
+			out.PDF.Margin = v
+		}
+	}
+	pdfJPEG := widget.NewCheck("Embed pages as JPEG", func(b bool) { out.PDF.JPEG = b })
+	pdfJPEG.Checked = out.PDF.JPEG
+	pageNumbers := widget.NewCheck("Page numbers", func(b bool) { out.PDF.PageNumbers = b })
+	pageNumbers.Checked = out.PDF.PageNumbers
+	titlePage := widget.NewCheck("Title page", func(b bool) { out.PDF.TitlePage = b })
+	titlePage.Checked = out.PDF.TitlePage
+
+	space, _ := e.Images.ColorSpace.Get()
+	spaces := make([]string, len(data.ColorSpaces))
+	for i, v := range data.ColorSpaces {
+		spaces[i] = v.String()
+	}
+	spaceSelect := widget.NewSelect(spaces, func(s string) {
+		space = data.ColorSpaces[slices.Index(spaces, s)]
+	})
+	spaceSelect.SetSelected(space.String())
+	if e.Images.Length() > 0 {
+		spaceSelect.Disable()
+	}
+	update()
+
+	content := widget.NewForm(
+		widget.NewFormItem("Scale (%)", scale),
+		widget.NewFormItem("Target Width", intEntry(&out.Width, "Original")),
+		widget.NewFormItem("Max Width", intEntry(&out.MaxWidth, "No limit")),
+		widget.NewFormItem("Max Height", intEntry(&out.MaxHeight, "No limit")),
+		widget.NewFormItem("Max Megapixels", megapixels),
+		widget.NewFormItem("Resampling", resampler),
+		widget.NewFormItem("", retina),
+		widget.NewFormItem("", layoutMap),
+		widget.NewFormItem("Working Space", spaceSelect),
+		widget.NewFormItem("", embed),
+		widget.NewFormItem("", optimize),
+		widget.NewFormItem("", lossy),
+		widget.NewFormItem("Colors", colors),
+		widget.NewFormItem("", dither),
+		widget.NewFormItem("PDF Paper", paper),
+		widget.NewFormItem("PDF Margin (mm)", margin),
+		widget.NewFormItem("", pdfJPEG),
+		widget.NewFormItem("", pageNumbers),
+		widget.NewFormItem("", titlePage),
+		widget.NewFormItem("Result", size),
+	)
+	d := dialog.NewCustomConfirm("Output Size", "Apply", "Cancel", content, func(ok bool) {
+		if ok {
+			e.Images.Output.Set(out)
+			e.Images.ColorSpace.Set(space)
+			fyne.CurrentApp().Preferences().SetInt(prefColorSpace, int(space))
+		}
+	}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"image"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+type imagePreview struct {
+	widget.BaseWidget
+	Editor *editor
+
+	Redact      data.RedactionStyle
+	Redacting   bool
+	dragging    bool
+	start, stop image.Point
+
+	content   image.Image
+	columns   data.ColumnLayout
+	image     *canvas.Image
+	draftRect *canvas.Rectangle
+}
+
+func newImagePreview(e *editor) *imagePreview {
+	p := &imagePreview{Editor: e}
+	p.ExtendBaseWidget(p)
+
+	th := theme.Current()
+	v := fyne.CurrentApp().Settings().ThemeVariant()
+	p.content = e.Images.Compose()
+	p.columns = e.Images.ColumnLayout(p.content)
+	p.image = canvas.NewImageFromImage(p.decorated())
+	p.image.FillMode = canvas.ImageFillContain
+	p.image.ScaleMode = canvas.ImageScaleFastest
+	p.image.SetMinSize(imageSizeByBounds(p.image.Image.Bounds()))
+	p.draftRect = canvas.NewRectangle(th.Color(theme.ColorNameSelection, v))
+	p.draftRect.StrokeColor = th.Color(theme.ColorNameError, v)
+	p.draftRect.StrokeWidth = th.Size(theme.SizeNameInputBorder)
+	p.draftRect.Hide()
+	return p
+}
+
+func (p *imagePreview) Update() {
+	p.content = p.Editor.Images.Compose()
+	p.Rearrange()
+}
+
+func (p *imagePreview) Rearrange() {
+	p.columns = p.Editor.Images.ColumnLayout(p.content)
+	p.Decorate()
+}
+
+func (p *imagePreview) Decorate() {
+	p.image.Image = p.decorated()
+	p.image.Refresh()
+}
+
+func (p *imagePreview) decorated() image.Image {
+	d, _ := p.Editor.Images.Decoration.Get()
+	return d.Apply(p.columns.Apply(p.content))
+}
+
+func (p *imagePreview) inset() image.Point {
+	d, _ := p.Editor.Images.Decoration.Get()
+	return d.Inset()
+}
+
+func (p *imagePreview) imageRect() (offset fyne.Position, scale float32) {
+	b := p.image.Image.Bounds()
+	size := p.Size()
+	scale = float32(math.Min(float64(size.Width)/float64(b.Dx()), float64(size.Height)/float64(b.Dy())))
+	offset = fyne.NewPos((size.Width-float32(b.Dx())*scale)/2, (size.Height-float32(b.Dy())*scale)/2)
+	return offset, scale
+}
+
+func (p *imagePreview) toMerged(pos fyne.Position) image.Point {
+	offset, scale := p.imageRect()
+	pos = pos.Subtract(offset)
+	sheet := image.Pt(int(math.Round(float64(pos.X/scale))), int(math.Round(float64(pos.Y/scale)))).Sub(p.inset())
+	return p.columns.FromSheet(sheet)
+}
+
+func (p *imagePreview) Dragged(e *fyne.DragEvent) {
+	if !p.Redacting {
+		return
+	}
+	if !p.dragging {
+		p.dragging = true
+		p.start = p.toMerged(e.Position.Subtract(e.Dragged))
+		p.draftRect.Show()
+	}
+	p.stop = p.toMerged(e.Position)
+
+	offset, scale := p.imageRect()
+	r := image.Rectangle{p.columns.ToSheet(p.start), p.columns.ToSheet(p.stop)}.Canon().Add(p.inset())
+	p.draftRect.Move(offset.AddXY(float32(r.Min.X)*scale, float32(r.Min.Y)*scale))
+	p.draftRect.Resize(fyne.NewSize(float32(r.Dx())*scale, float32(r.Dy())*scale))
+}
+
+func (p *imagePreview) DragEnd() {
+	if !p.dragging {
+		return
+	}
+	p.dragging = false
+	p.draftRect.Hide()
+	if r := (image.Rectangle{p.start, p.stop}).Canon(); !r.Empty() {
+		p.Editor.Images.AddRedaction(r, p.Redact)
+		p.Update()
+	}
+}
+
+func (p *imagePreview) Tapped(e *fyne.PointEvent) {
+	if p.Redacting {
+		return
+	}
+	if img := p.Editor.Images.ImageAt(p.toMerged(e.Position)); img != nil {
+		p.Editor.list.SetSelection([]*data.Image{img})
+		p.Editor.ScrollToImage(img)
+	}
+}
+
+func (p *imagePreview) Cursor() desktop.Cursor {
+	if p.Redacting {
+		return desktop.CrosshairCursor
+	}
+	return desktop.DefaultCursor
+}
+
+func (p *imagePreview) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewStack(p.image, container.NewWithoutLayout(p.draftRect)))
+}
+
+func (e editor) newRedactSelect(preview *imagePreview) *widget.Select {
+	options := []string{"None"}
+	for _, v := range data.RedactionStyles {
+		options = append(options, v.String())
+	}
+	s := widget.NewSelect(options, func(s string) {
+		preview.Redacting = false
+		for _, v := range data.RedactionStyles {
+			if v.String() == s {
+				preview.Redact, preview.Redacting = v, true
+			}
+		}
+	})
+	s.SetSelectedIndex(0)
+	return s
+}
@@ -0,0 +1,223 @@
+package internal
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+	"strconv"
+	"sync/atomic"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+const (
+	prefSeamThreshold    = "seamThreshold"
+	defaultSeamThreshold = 0.04
+)
+
+func seamThreshold() float64 {
+	return fyne.CurrentApp().Preferences().FloatWithFallback(prefSeamThreshold, defaultSeamThreshold)
+}
+
+type imageSeam struct {
+	widget.BaseWidget
+
+	List         *imageList
+	Above, Below *data.Image
+
+	badge    *fyne.Container
+	listener binding.DataListener
+
+	// measured counts badge refreshes, so a slow measurement cannot
+	// replace the badge of a later crop.
+	measured atomic.Int64
+}
+
+func newImageSeam(list *imageList, above, below *data.Image) *imageSeam {
+	s := &imageSeam{List: list, Above: above, Below: below}
+	s.ExtendBaseWidget(s)
+	s.listener = binding.NewDataListener(func() {
+		s.RefreshBadge()
+		if list.heatmap {
+			list.refreshHeatmaps()
+		}
+	})
+	above.Crop.AddListener(s.listener)
+	below.Crop.AddListener(s.listener)
+	return s
+}
+
+// unbind stops the seam from following the crops of its images once the
+// list has replaced it.
+func (s *imageSeam) unbind() {
+	s.Above.Crop.RemoveListener(s.listener)
+	s.Below.Crop.RemoveListener(s.listener)
+}
+
+// RefreshBadge measures the seam in the background and shows its score
+// when done.
+func (s *imageSeam) RefreshBadge() {
+	if s.badge == nil {
+		return
+	}
+	n := s.measured.Add(1)
+	if s.separator().Height() > 0 {
+		s.badge.RemoveAll()
+		return
+	}
+	threshold := seamThreshold()
+	go func() {
+		q := data.MeasureSeam(s.Above, s.Below)
+		if s.measured.Load() != n {
+			return
+		}
+		s.badge.RemoveAll()
+		if !q.Measured {
+			return
+		}
+		clr := theme.ColorNameSuccess
+		switch q.Grade(threshold) {
+		case data.SeamFair:
+			clr = theme.ColorNameWarning
+		case data.SeamPoor:
+			clr = theme.ColorNameError
+		}
+		s.badge.Add(newImageBadge(fmt.Sprintf("%.1f%%", q.Score()*100), clr))
+	}()
+}
+
+func (s *imageSeam) separator() data.Separator {
+	return s.List.Editor.Images.SeparatorAfter(s.Above)
+}
+
+func (s *imageSeam) MinSize() fyne.Size {
+	w := imageBaseSize().Width
+	h := w * float32(s.separator().Height()) / float32(s.Above.Bounds().Dx())
+	return fyne.NewSize(w, max(h, s.Theme().Size(theme.SizeNamePadding)))
+}
+
+func (s *imageSeam) TappedSecondary(e *fyne.PointEvent) {
+	override, _ := s.Above.Separator.Get()
+	set := func(v *data.Separator) {
+		s.Above.Separator.Set(v)
+		s.List.Refresh()
+	}
+
+	items := []*fyne.MenuItem{
+		{Label: "Use Default", Checked: override == nil, Action: func() { set(nil) }},
+		fyne.NewMenuItemSeparator(),
+	}
+	for _, style := range data.SeparatorStyles {
+		items = append(items, &fyne.MenuItem{
+			Label:   style.String(),
+			Checked: override != nil && override.Style == style,
+			Action: func() {
+				v, _ := s.List.Editor.Images.Separator.Get()
+				v.Style = style
+				set(&v)
+			},
+		})
+	}
+	items = append(items,
+		fyne.NewMenuItemSeparator(),
+		&fyne.MenuItem{Label: "Show Mismatch Heatmap", Checked: s.List.heatmap, Action: func() {
+			s.List.heatmap = !s.List.heatmap
+			s.List.refreshHeatmaps()
+		}},
+		&fyne.MenuItem{Label: "Mismatch Threshold...", Action: s.List.Editor.ShowSeamThresholdDialog},
+	)
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("Seam", items...),
+		fyne.CurrentApp().Driver().CanvasForObject(s), e.AbsolutePosition)
+}
+
+func (s *imageSeam) CreateRenderer() fyne.WidgetRenderer {
+	raster := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		s.separator().Draw(img, img.Bounds())
+		return img
+	})
+	s.badge = container.NewHBox()
+	s.RefreshBadge()
+	return widget.NewSimpleRenderer(container.NewStack(raster, container.NewCenter(s.badge)))
+}
+
+func (e editor) ShowSeamThresholdDialog() {
+	threshold := binding.BindPreferenceFloat(prefSeamThreshold, fyne.CurrentApp().Preferences())
+	threshold.Set(seamThreshold())
+
+	slider := widget.NewSliderWithData(0.005, 0.2, threshold)
+	slider.Step = 0.005
+	status := widget.NewLabel("")
+	update := func() {
+		v, _ := threshold.Get()
+		status.SetText(fmt.Sprintf("%d seams above %.1f%% mismatch", len(e.Images.PoorSeams(v)), v*100))
+		e.list.refreshSeamBadges()
+	}
+	listener := binding.NewDataListener(update)
+	threshold.AddListener(listener)
+
+	d := dialog.NewCustom("Mismatch Threshold", "Close", container.NewVBox(slider, status), e)
+	d.SetOnClosed(func() { threshold.RemoveListener(listener) })
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
+
+func (e editor) ShowSeparatorDialog() {
+	sep, _ := e.Images.Separator.Get()
+
+	styles := make([]string, len(data.SeparatorStyles))
+	for i, v := range data.SeparatorStyles {
+		styles[i] = v.String()
+	}
+	style := widget.NewSelect(styles, func(s string) {
+		sep.Style = data.SeparatorStyles[slices.Index(styles, s)]
+	})
+	style.SetSelected(sep.Style.String())
+	size := widget.NewEntry()
+	size.SetText(strconv.Itoa(sep.Size))
+	size.OnChanged = func(s string) {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			sep.Size = v
+		}
+	}
+	colorButton := func(title string, value *color.NRGBA) *widget.Button {
+		return widget.NewButton(title+"...", func() {
+			d := dialog.NewColorPicker(title, "", func(c color.Color) {
+				*value = color.NRGBAModel.Convert(c).(color.NRGBA)
+			}, e)
+			d.Advanced = true
+			d.SetColor(*value)
+			d.Show()
+		})
+	}
+	reset := widget.NewCheck("Reset per-seam styles", nil)
+
+	content := widget.NewForm(
+		widget.NewFormItem("Style", style),
+		widget.NewFormItem("Size", size),
+		widget.NewFormItem("", container.NewHBox(colorButton("Color", &sep.Color), colorButton("Background", &sep.Background))),
+		widget.NewFormItem("", reset),
+	)
+	d := dialog.NewCustomConfirm("Separators", "Apply", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if reset.Checked {
+			list, _ := e.Images.Get()
+			for _, v := range list {
+				v.Separator.Set(nil)
+			}
+		}
+		e.Images.Separator.Set(sep)
+	}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width, 0))
+	d.Show()
+}
@@ -25,3 +25,23 @@ type ShortcutClose struct{}
 func (ShortcutClose) ShortcutName() string  { return "Close" }
 func (ShortcutClose) Key() fyne.KeyName     { return fyne.KeyW }
 func (ShortcutClose) Mod() fyne.KeyModifier { return fyne.KeyModifierShortcutDefault }
+
+type ShortcutSelectAll struct{}
+
+func (ShortcutSelectAll) ShortcutName() string  { return "SelectAll" }
+func (ShortcutSelectAll) Key() fyne.KeyName     { return fyne.KeyA }
+func (ShortcutSelectAll) Mod() fyne.KeyModifier { return fyne.KeyModifierShortcutDefault }
+
+type ShortcutUndo struct{}
+
+func (ShortcutUndo) ShortcutName() string  { return "Undo" }
+func (ShortcutUndo) Key() fyne.KeyName     { return fyne.KeyZ }
+func (ShortcutUndo) Mod() fyne.KeyModifier { return fyne.KeyModifierShortcutDefault }
+
+type ShortcutRedo struct{}
+
+func (ShortcutRedo) ShortcutName() string { return "Redo" }
+func (ShortcutRedo) Key() fyne.KeyName    { return fyne.KeyZ }
+func (ShortcutRedo) Mod() fyne.KeyModifier {
+	return fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift
+}
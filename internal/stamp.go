@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+func (e editor) ShowStampDialog() {
+	prefs := fyne.CurrentApp().Preferences()
+	st, _ := e.Images.Stamp.Get()
+
+	markEnabled := widget.NewCheck("Watermark", func(b bool) { st.Watermark.Enabled = b })
+	markText := widget.NewEntry()
+	markText.OnChanged = func(s string) { st.Watermark.Text = s }
+	markImage := widget.NewEntry()
+	markImage.SetPlaceHolder("Image file (overrides text)")
+	markImage.OnChanged = func(s string) { st.Watermark.ImageURI = s }
+	browse := widget.NewButton("Browse...", func() {
+		d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			markImage.SetText(reader.URI().String())
+		}, e)
+		d.SetFilter(storage.NewMimeTypeFileFilter([]string{"image/*"}))
+		d.Show()
+	})
+	positions := make([]string, len(data.StampPositions))
+	for i, v := range data.StampPositions {
+		positions[i] = v.String()
+	}
+	markPosition := widget.NewSelect(positions, func(s string) {
+		st.Watermark.Position = data.StampPositions[slices.Index(positions, s)]
+	})
+	markOpacity := widget.NewSlider(0.05, 1)
+	markOpacity.Step = 0.05
+	markOpacity.OnChanged = func(f float64) { st.Watermark.Opacity = f }
+	markSize := widget.NewEntry()
+	markSize.OnChanged = func(s string) {
+		if v, err := strconv.ParseFloat(s, 64); err == nil && v > 0 {
+			st.Watermark.Size = v
+		}
+	}
+	markTile := widget.NewCheck("Tile", func(b bool) { st.Watermark.Tile = b })
+	markColor := widget.NewButton("Color...", func() {
+		d := dialog.NewColorPicker("Watermark Color", "", func(c color.Color) {
+			st.Watermark.Color = color.NRGBAModel.Convert(c).(color.NRGBA)
+		}, e)
+		d.Advanced = true
+		d.SetColor(st.Watermark.Color)
+		d.Show()
+	})
+
+	footerEnabled := widget.NewCheck("Footer", func(b bool) { st.Footer.Enabled = b })
+	footerDate := widget.NewCheck("Date", func(b bool) { st.Footer.Date = b })
+	footerApp := widget.NewEntry()
+	footerApp.SetPlaceHolder("Source app")
+	footerApp.OnChanged = func(s string) { st.Footer.AppName = s }
+	footerText := widget.NewEntry()
+	footerText.SetPlaceHolder("Custom text")
+	footerText.OnChanged = func(s string) { st.Footer.Text = s }
+
+	load := func(s data.Stamp) {
+		markEnabled.SetChecked(s.Watermark.Enabled)
+		markText.SetText(s.Watermark.Text)
+		markImage.SetText(s.Watermark.ImageURI)
+		markPosition.SetSelectedIndex(slices.Index(data.StampPositions, s.Watermark.Position))
+		markOpacity.SetValue(s.Watermark.Opacity)
+		markSize.SetText(fmt.Sprint(s.Watermark.Size))
+		markTile.SetChecked(s.Watermark.Tile)
+		footerEnabled.SetChecked(s.Footer.Enabled)
+		footerDate.SetChecked(s.Footer.Date)
+		footerApp.SetText(s.Footer.AppName)
+		footerText.SetText(s.Footer.Text)
+		st = s
+	}
+	load(st)
+
+	presets := widget.NewSelect(nil, nil)
+	refreshPresets := func() {
+		var names []string
+		for name := range data.LoadStampPresets(prefs) {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		presets.SetOptions(names)
+	}
+	refreshPresets()
+	presets.PlaceHolder = "Presets"
+	presets.OnChanged = func(name string) {
+		if s, ok := data.LoadStampPresets(prefs)[name]; ok {
+			load(s)
+		}
+	}
+	savePreset := widget.NewButton("Save Preset...", func() {
+		name := widget.NewEntry()
+		name.SetText(presets.Selected)
+		dialog.ShowForm("Save Preset", "Save", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", name)}, func(ok bool) {
+			if !ok || name.Text == "" {
+				return
+			}
+			if err := data.SaveStampPreset(prefs, name.Text, st); err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			refreshPresets()
+			presets.Selected = name.Text
+			presets.Refresh()
+		}, e)
+	})
+	deletePreset := widget.NewButton("Delete", func() {
+		if presets.Selected == "" {
+			return
+		}
+		data.DeleteStampPreset(prefs, presets.Selected)
+		presets.ClearSelected()
+		refreshPresets()
+	})
+
+	content := container.NewVBox(
+		container.NewBorder(nil, nil, nil, container.NewHBox(savePreset, deletePreset), presets),
+		widget.NewSeparator(),
+		markEnabled,
+		widget.NewForm(
+			widget.NewFormItem("Text", markText),
+			widget.NewFormItem("Image", container.NewBorder(nil, nil, nil, browse, markImage)),
+			widget.NewFormItem("Position", container.NewHBox(markPosition, markTile)),
+			widget.NewFormItem("Opacity", markOpacity),
+			widget.NewFormItem("Size", container.NewBorder(nil, nil, nil, markColor, markSize)),
+		),
+		widget.NewSeparator(),
+		footerEnabled,
+		widget.NewForm(
+			widget.NewFormItem("Source", footerApp),
+			widget.NewFormItem("Text", footerText),
+			widget.NewFormItem("", footerDate),
+		),
+	)
+	d := dialog.NewCustomConfirm("Watermark & Footer", "Apply", "Cancel", content, func(ok bool) {
+		if ok {
+			e.Images.Stamp.Set(st)
+		}
+	}, e)
+	d.Resize(fyne.NewSize(imageBaseSize().Width*1.2, 0))
+	d.Show()
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/yukkie8058/rollshot/data"
+)
+
+var tileSizes = []int{256, 512, 1024}
+
+func (e editor) ShowTileExportDialog() {
+	opts := data.DefaultTileOptions
+	sizes := make([]string, len(tileSizes))
+	for i, v := range tileSizes {
+		sizes[i] = strconv.Itoa(v)
+	}
+	size := widget.NewSelect(sizes, func(s string) { opts.Size, _ = strconv.Atoi(s) })
+	size.SetSelected(strconv.Itoa(opts.Size))
+	jpeg := widget.NewCheck("Use JPEG tiles", func(b bool) { opts.JPEG = b })
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Tile Size", size),
+		widget.NewFormItem("", jpeg),
+	}
+	dialog.ShowForm("Export Tiles", "Choose Folder...", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		d := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			if dir == nil {
+				return
+			}
+			if err := e.Images.WriteTiles(dir, opts, dir.Name()); err != nil {
+				dialog.ShowError(err, e)
+				return
+			}
+			e.showSavedPopUp("Exported successfully")
+		}, e)
+		d.Show()
+	}, e)
+}